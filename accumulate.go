@@ -0,0 +1,108 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// summaryMetric - a per repo count the summary accumulates, label names it in
+// text output.
+type summaryMetric struct {
+	label string
+	value func(d dataStruct) int
+}
+
+// summaryMetrics - the accumulated metrics by name, also the -highlight choices.
+var summaryMetrics = map[string]summaryMetric{
+	"watchers":    {"Watchers", func(d dataStruct) int { return d.WatchersCount }},
+	"stars":       {"Stars", func(d dataStruct) int { return d.WatchersCount }}, // watchers_count is the star count
+	"forks":       {"Forks", func(d dataStruct) int { return d.ForksCount }},
+	"open_issues": {"OpenIssues", func(d dataStruct) int { return d.OpenIssuesCount }},
+	"subscribers": {"Subscribers", func(d dataStruct) int { return d.SubscribersCount }},
+}
+
+// summaryMetricNames - returns the sorted names of summaryMetrics.
+func summaryMetricNames() []string {
+	var names []string
+	for k := range summaryMetrics {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// metricTotals - the total and max of a metric over a set of repos, most
+// holds the repos at max in tiebreak order.
+type metricTotals struct {
+	total int
+	max   int
+	most  []dataStruct
+}
+
+// mostTxt - returns the comma joined names of the most repos, "<NONE>" when
+// no repo counted above 0.
+func (m *metricTotals) mostTxt(fullname bool) string {
+	if len(m.most) == 0 {
+		return "<NONE>"
+	}
+	names := make([]string, len(m.most))
+	for i, v := range m.most {
+		names[i] = v.displayName(fullname)
+	}
+	return strings.Join(names, ",")
+}
+
+// tieBreaks - the -tiebreak orderings of repos tied at a metric's max.
+var tieBreaks = map[string]func(a, b dataStruct) bool{
+	"name": func(a, b dataStruct) bool {
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.FullName < b.FullName
+	},
+	"stars":    func(a, b dataStruct) bool { return a.WatchersCount > b.WatchersCount },
+	"pushedat": func(a, b dataStruct) bool { return a.PushedAt.After(b.PushedAt) },
+}
+
+// defTieBreak - the default -tiebreak.
+const defTieBreak = "name"
+
+// tieBreakNames - returns the sorted names of tieBreaks.
+func tieBreakNames() []string {
+	var names []string
+	for k := range tieBreaks {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// accumulate - returns the metricTotals of each of metrics over data keyed
+// like metrics, repos tied at a max are ordered by tieBreaks[tiebreak].
+func accumulate(data []dataStruct, metrics map[string]summaryMetric, tiebreak string) map[string]*metricTotals {
+	acc := make(map[string]*metricTotals, len(metrics))
+	for k := range metrics {
+		acc[k] = &metricTotals{}
+	}
+	for _, v := range data {
+		for k, m := range metrics {
+			t, n := acc[k], m.value(v)
+			t.total += n
+			if n > t.max {
+				t.max = n
+				t.most = append(t.most[:0], v)
+			} else if n > 0 && n == t.max {
+				t.most = append(t.most, v)
+			}
+		}
+	}
+	less := tieBreaks[tiebreak]
+	for _, t := range acc {
+		sort.SliceStable(t.most, func(i, j int) bool { return less(t.most[i], t.most[j]) })
+	}
+	return acc
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccumulate(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, 1, n, 0, 0, 0, 0, time.UTC) }
+	repos := []dataStruct{
+		{Name: "c", WatchersCount: 5, OpenIssuesCount: 2, PushedAt: day(1)},
+		{Name: "a", WatchersCount: 5, OpenIssuesCount: 0, PushedAt: day(3)},
+		{Name: "b", WatchersCount: 1, OpenIssuesCount: 2, PushedAt: day(2)},
+	}
+	for _, tc := range []struct {
+		name     string
+		data     []dataStruct
+		metric   string
+		tiebreak string
+		total    int
+		max      int
+		most     string
+	}{
+		{"empty", nil, "watchers", defTieBreak, 0, 0, "<NONE>"},
+		{"all zero", []dataStruct{{Name: "z"}, {Name: "y"}}, "forks", defTieBreak, 0, 0, "<NONE>"},
+		{"single", repos[2:], "watchers", defTieBreak, 1, 1, "b"},
+		{"tie by name", repos, "watchers", "name", 11, 5, "a,c"},
+		{"tie by pushedat", repos, "open_issues", "pushedat", 4, 2, "b,c"},
+		{"tie by stars", repos, "open_issues", "stars", 4, 2, "c,b"},
+		{"max replaces ties", append([]dataStruct{{Name: "d", WatchersCount: 1}, {Name: "e", WatchersCount: 1}}, repos[0]), "watchers", defTieBreak, 7, 5, "c"},
+	} {
+		acc := accumulate(tc.data, summaryMetrics, tc.tiebreak)
+		if len(acc) != len(summaryMetrics) {
+			t.Fatalf("%s: got %d metrics want %d", tc.name, len(acc), len(summaryMetrics))
+		}
+		m := acc[tc.metric]
+		if m.total != tc.total || m.max != tc.max || m.mostTxt(false) != tc.most {
+			t.Errorf("%s: %s total:%d max:%d most:%s want %d %d %s", tc.name, tc.metric, m.total, m.max, m.mostTxt(false), tc.total, tc.max, tc.most)
+		}
+	}
+}
+
+func TestTieBreaks(t *testing.T) {
+	a := dataStruct{Name: "x", FullName: "a/x", WatchersCount: 1, PushedAt: time.Unix(100, 0)}
+	b := dataStruct{Name: "x", FullName: "b/x", WatchersCount: 2, PushedAt: time.Unix(200, 0)}
+	for name, want := range map[string]bool{"name": true, "stars": false, "pushedat": false} {
+		if got := tieBreaks[name](a, b); got != want {
+			t.Errorf("tieBreaks[%s](a, b):%t want %t", name, got, want)
+		}
+	}
+	if got := strings.Join(tieBreakNames(), ","); got != "name,pushedat,stars" {
+		t.Errorf("tieBreakNames:%s", got)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// A GitHub App authenticates as one of its installations with an
+// installation token, sent like a personal access token so one from
+// elsewhere works as $GITHUB_TOKEN or -tokenfile as is. Given -appid,
+// -appkey and -installationid ghrepo mints one itself, signing a short
+// lived app JWT with the key and exchanging it for the installation's token.
+
+// readAppKey - returns the RSA private key in the PEM file, as downloaded
+// from the app's settings, PKCS#1 or PKCS#8.
+func readAppKey(file string) (*rsa.PrivateKey, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s holds no PEM key", file)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	key, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s holds no RSA key", file)
+	}
+	return key, nil
+}
+
+// appJWT - returns the RS256 JWT authenticating as app appID as of now,
+// issued a minute early for clock drift and expiring within GitHub's 10m.
+func appJWT(appID string, key *rsa.PrivateKey, now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	claims, err := json.Marshal(struct {
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+		Iss string `json:"iss"`
+	}{now.Add(-time.Minute).Unix(), now.Add(9 * time.Minute).Unix(), appID})
+	if err != nil {
+		return "", err
+	}
+	unsigned := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// mintInstallationToken - returns an installation token for installation of
+// app appID, keyed by keyfile, from the api urlname is on.
+func mintInstallationToken(ctx context.Context, urlname, appID, keyfile, installation string) (string, error) {
+	key, err := readAppKey(keyfile)
+	if err != nil {
+		return "", err
+	}
+	jwt, err := appJWT(appID, key, time.Now())
+	if err != nil {
+		return "", err
+	}
+	tokenURL := apiRoot(urlname) + "/app/installations/" + installation + "/access_tokens"
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(""))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	res, err := apiClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = res.Body.Close() }()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	var tok struct {
+		Token   string `json:"token"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &tok)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", fmt.Errorf("POST %s failed status:%s %s", tokenURL, res.Status, tok.Message)
+	}
+	if tok.Token == "" {
+		return "", errors.New("no token in the access_tokens response")
+	}
+	return tok.Token, nil
+}
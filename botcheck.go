@@ -0,0 +1,97 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defBots - the default -bots, accounts whose commits don't count as activity.
+const defBots = "dependabot[bot],renovate[bot],github-actions[bot],dependabot-preview[bot]"
+
+// commitAuthor - the parts of a commits endpoint entry naming its author.
+type commitAuthor struct {
+	Author *struct {
+		Login string `json:"login"`
+		Type  string `json:"type"` // Bot for app accounts
+	} `json:"author"` // nil when the commit email matches no account
+	Commit struct {
+		Author struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commit"`
+}
+
+// isBot - returns whether c was authored by a bot, an app account, a login
+// ending in [bot] or one of bots.
+func (c commitAuthor) isBot(bots []string) bool {
+	name := c.Commit.Author.Name
+	if c.Author != nil {
+		if c.Author.Type == "Bot" {
+			return true
+		}
+		name = c.Author.Login
+	}
+	if strings.HasSuffix(strings.ToLower(name), "[bot]") {
+		return true
+	}
+	for _, b := range bots {
+		if strings.EqualFold(name, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBotPushes - sets BotOnly on those of the n most recently pushed
+// repos of data whose every commit since window ago is by a bot, a commits
+// request each, and returns how many were checked.
+func checkBotPushes(ctx context.Context, data []dataStruct, n int, window time.Duration, bots []string, fo fetchOpts) (int, error) {
+	idx := make([]int, len(data))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return data[idx[a]].PushedAt.After(data[idx[b]].PushedAt) })
+	if n > len(idx) {
+		n = len(idx)
+	}
+	since := url.QueryEscape(time.Now().Add(-window).UTC().Format(time.RFC3339))
+	for k, i := range idx[:n] {
+		if data[i].URL == "" {
+			return k, fmt.Errorf("no url to check commits for repo:%s", data[i].Name)
+		}
+		if k > 0 {
+			if err := pageWait(ctx, fo); err != nil {
+				return k, err
+			}
+		}
+		_, body, err := apiGet(ctx, pageURL(data[i].URL+"/commits?since="+since, 1, 100))
+		if err != nil {
+			return k, fmt.Errorf("repo:%s commits err:%w", data[i].Name, err)
+		}
+		var commits []commitAuthor
+		if err = apiUnmarshal(body, &commits); err != nil {
+			return k, fmt.Errorf("repo:%s commits err:%w", data[i].Name, err)
+		}
+		botOnly := len(commits) > 0
+		for _, c := range commits {
+			if !c.isBot(bots) {
+				botOnly = false
+				break
+			}
+		}
+		data[i].BotOnly = botOnly
+		if botOnly && fo.verbose > 0 {
+			log.Printf("repo:%s all %d commits in the last %.0fd are by bots\n", data[i].Name, len(commits), days(window))
+		}
+	}
+	return n, nil
+}
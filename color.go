@@ -0,0 +1,91 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ansiColors - the -colorscheme color names and their ansi sgr codes.
+var ansiColors = map[string]string{
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+}
+
+// defColorScheme - the -colorscheme used when -color is given without one.
+const defColorScheme = "fresh=30d:green,stale=180d:yellow,dead=365d:red"
+
+// colorRule - one name=age:color entry of a -colorscheme.
+type colorRule struct {
+	name  string
+	age   time.Duration
+	color string // ansi sgr code
+}
+
+// parseColorScheme - returns the rules of scheme, a comma separated list of
+// name=age:color, sorted by age, "" means defColorScheme.
+func parseColorScheme(scheme string) ([]colorRule, error) {
+	if strings.TrimSpace(scheme) == "" {
+		scheme = defColorScheme
+	}
+	var rules []colorRule
+	for _, entry := range splitList(scheme) {
+		eq := strings.IndexByte(entry, '=')
+		colon := strings.LastIndexByte(entry, ':')
+		if eq <= 0 || colon < eq {
+			return nil, fmt.Errorf("colorscheme entry %q not name=age:color", entry)
+		}
+		age, err := parseAge(entry[eq+1 : colon])
+		if err != nil {
+			return nil, fmt.Errorf("colorscheme entry %q: %v", entry, err)
+		}
+		code, ok := ansiColors[strings.ToLower(entry[colon+1:])]
+		if !ok {
+			return nil, fmt.Errorf("colorscheme entry %q: unknown color %q valid:%s", entry, entry[colon+1:], strings.Join(colorNames(), ","))
+		}
+		for _, r := range rules {
+			if r.age == age {
+				return nil, fmt.Errorf("colorscheme entries %s and %s have the same age", r.name, entry[:eq])
+			}
+		}
+		rules = append(rules, colorRule{entry[:eq], age, code})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].age < rules[j].age })
+	return rules, nil
+}
+
+// colorNames - returns the sorted names of ansiColors.
+func colorNames() []string {
+	var names []string
+	for k := range ansiColors {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// colorFor - returns the ansi code of the first of rules whose age covers a
+// repo pushedAt as of now, repos older than every rule get the last rule's.
+func colorFor(rules []colorRule, pushedAt, now time.Time) string {
+	age := ageOf(pushedAt, now)
+	for _, r := range rules {
+		if age < r.age {
+			return r.color
+		}
+	}
+	return rules[len(rules)-1].color
+}
+
+// colorize - returns s wrapped in the ansi sgr code.
+func colorize(s, code string) string {
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
@@ -6,56 +6,256 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"embed"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"text/template"
 	"time"
+	"unicode/utf8"
 )
 
 type dataStruct struct {
-	Name            string    `json:"name"`
-	PushedAt        time.Time `json:"pushed_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
-	WatchersCount   int       `json:"watchers_count"`
-	OpenIssuesCount int       `json:"open_issues_count"`
+	ID                int64          `json:"id"`
+	Name              string         `json:"name"`
+	FullName          string         `json:"full_name"`
+	Description       string         `json:"description"`
+	URL               string         `json:"url"`
+	HTMLURL           string         `json:"html_url"`
+	CreatedAt         time.Time      `json:"created_at"`
+	PushedAt          time.Time      `json:"pushed_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	WatchersCount     int            `json:"watchers_count"`    // actually the stargazers count
+	SubscribersCount  int            `json:"subscribers_count"` // true watchers, only from the repo endpoint
+	OpenIssuesCount   int            `json:"open_issues_count"`
+	ForksCount        int            `json:"forks_count"`
+	Size              int64          `json:"size"` // KiB
+	Disabled          bool           `json:"disabled"`
+	HasIssues         bool           `json:"has_issues"`
+	HasWiki           bool           `json:"has_wiki"`
+	HasProjects       bool           `json:"has_projects"`
+	Private           bool           `json:"private"`
+	MirrorURL         string         `json:"mirror_url"`
+	Language          string         `json:"language"` // primary language, "" when GitHub detected none
+	Topics            []string       `json:"topics"`
+	Owner             ownerStruct    `json:"owner"`
+	License           *licenseStruct `json:"license"`            // nil when GitHub detected no license
+	Releases          *int           `json:"releases,omitempty"` // not a repo object field, only set by -releases
+	BotOnly           bool           `json:"bot_only,omitempty"` // not a repo object field, only set by -botcheck
+	DefaultBranch     string         `json:"default_branch"`
+	Protected         *bool          `json:"protected,omitempty"`          // not a repo object field, only set by -protection
+	PushedAgeSeconds  *int64         `json:"pushed_age_seconds,omitempty"` // not a repo object field, only set by -relative
+	UpdatedAgeSeconds *int64         `json:"updated_age_seconds,omitempty"`
 }
 
+// ownerStruct - the parts of a repo's nested owner object used.
+type ownerStruct struct {
+	Login string `json:"login"`
+	Type  string `json:"type"` // User or Organization
+}
+
+// licenseStruct - the parts of a repo's nested license object used.
+type licenseStruct struct {
+	Key    string `json:"key"`
+	SPDXID string `json:"spdx_id"`
+	Name   string `json:"name"`
+}
+
+// owner types of ownerStruct.Type
+const (
+	ownerUser = "User"
+	ownerOrg  = "Organization"
+)
+
 const version = "0.10"
 
+// noValue - placeholder String() shows for missing (null or empty) values.
+const noValue = "-"
+
+// orNoValue - returns s or noValue when s is empty.
+func orNoValue(s string) string {
+	if s == "" {
+		return noValue
+	}
+	return s
+}
+
+// timeOrNoValue - returns t formatted or noValue when t is zero (null).
+func timeOrNoValue(t time.Time) string {
+	if t.IsZero() {
+		return noValue
+	}
+	return t.String()
+}
+
 func (d dataStruct) String() string {
-	return fmt.Sprintf("[Name:%s UpdatedAt:%v PushedAt:%v WatchersCount:%d OpenIssuesCount:%d]",
-		d.Name, d.UpdatedAt, d.PushedAt, d.WatchersCount, d.OpenIssuesCount)
+	return fmt.Sprintf("[Name:%s FullName:%s Description:%s CreatedAt:%s UpdatedAt:%s PushedAt:%s WatchersCount:%d SubscribersCount:%d OpenIssuesCount:%d Disabled:%t MirrorURL:%s Language:%s License:%s Topics:%s]",
+		orNoValue(d.Name), orNoValue(d.FullName), orNoValue(d.Description), timeOrNoValue(d.CreatedAt), timeOrNoValue(d.UpdatedAt), timeOrNoValue(d.PushedAt),
+		d.WatchersCount, d.SubscribersCount, d.OpenIssuesCount, d.Disabled, orNoValue(d.MirrorURL), orNoValue(d.Language), d.licenseID(), orNoValue(strings.Join(d.Topics, ",")))
+}
+
+// displayName - returns the repo name or when full its full_name.
+func (d dataStruct) displayName(full bool) string {
+	if full && d.FullName != "" {
+		return d.FullName
+	}
+	if full && d.Owner.Login != "" {
+		return d.Owner.Login + "/" + d.Name
+	}
+	return d.Name
+}
+
+// licenseID - returns the SPDX id of the repo's license or noValue.
+func (d dataStruct) licenseID() string {
+	if d.License == nil {
+		return noValue
+	}
+	return orNoValue(d.License.SPDXID)
+}
+
+// protectedTxt - returns whether the repo's default branch is protected or
+// noValue when not checked, see -protection.
+func (d dataStruct) protectedTxt() string {
+	if d.Protected == nil {
+		return noValue
+	}
+	return strconv.FormatBool(*d.Protected)
+}
+
+// releasesTxt - returns the -releases count, noValue when not counted.
+func (d dataStruct) releasesTxt() string {
+	if d.Releases == nil {
+		return noValue
+	}
+	return strconv.Itoa(*d.Releases)
+}
+
+// owner - returns the owner part of the repo's full_name.
+func (d dataStruct) owner() string {
+	if i := strings.Index(d.FullName, "/"); i >= 0 {
+		return d.FullName[:i]
+	}
+	return d.FullName
+}
+
+// issueRatio - returns open issues per watcher, repos without watchers
+// count as having one so there's no divide by zero.
+func (d dataStruct) issueRatio() float64 {
+	watchers := d.WatchersCount
+	if watchers < 1 {
+		watchers = 1
+	}
+	return float64(d.OpenIssuesCount) / float64(watchers)
+}
+
+// span - returns the active lifespan of the repo from creation to last push,
+// repos never pushed (or pushed before created) have a span of 0.
+func (d dataStruct) span() time.Duration {
+	if d.PushedAt.IsZero() || d.PushedAt.Before(d.CreatedAt) {
+		return 0
+	}
+	return d.PushedAt.Sub(d.CreatedAt)
+}
+
+// empty - returns whether the repo looks to have no commits, a size of 0
+// and never pushed, a null pushed_at or one no later than created_at.
+func (d dataStruct) empty() bool {
+	return d.Size == 0 && !d.PushedAt.After(d.CreatedAt)
+}
+
+// ageOf - returns how long before now t was, 0 for a t after now so that a
+// skewed local clock doesn't give negative ages.
+func ageOf(t, now time.Time) time.Duration {
+	if age := now.Sub(t); age > 0 {
+		return age
+	}
+	return 0
+}
+
+// days - returns d expressed in days.
+func days(d time.Duration) float64 {
+	return d.Hours() / 24
 }
 
 type interface2 interface {
 	Title() string
+	FieldName() string
 	Name(int) string
 	Field(int) string
+	Repo(int) dataStruct
 	sort.Interface
 }
 
 type ghStruct struct {
-	title   string
-	sortasc bool
-	data    []dataStruct
+	title    string
+	sortasc  bool
+	data     []dataStruct
+	loc      *time.Location
+	prec     string             // -timeprecision of displayed timestamps
+	fullname bool               // display repos by full_name, used for multi-source reports
+	expr     *template.Template // the -sortexpr of sbyExpr sorts
+}
+
+// inLoc - returns t converted to loc, or t unchanged when loc is nil.
+func inLoc(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		return t
+	}
+	return t.In(loc)
+}
+
+// timePrecisions - the -timeprecision units, "" leaves timestamps as is.
+var timePrecisions = []string{"second", "minute", "day"}
+
+// truncTime - returns t truncated to precision, one of timePrecisions, days
+// start at midnight of t's location rather than UTC.
+func truncTime(t time.Time, precision string) time.Time {
+	switch precision {
+	case "second":
+		return t.Truncate(time.Second)
+	case "minute":
+		return t.Truncate(time.Minute)
+	case "day":
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+	return t
 }
 
 // byUpdateAt stuff  for sort.Sort
 type byUpdatedAt ghStruct
 
-func (a byUpdatedAt) Title() string      { return a.title }
-func (a byUpdatedAt) Name(i int) string  { return a.data[i].Name }
-func (a byUpdatedAt) Field(i int) string { return fmt.Sprintf("%v", a.data[i].UpdatedAt) }
-func (a byUpdatedAt) Len() int           { return len(a.data) }
-func (a byUpdatedAt) Swap(i, j int)      { a.data[i], a.data[j] = a.data[j], a.data[i] }
+func (a byUpdatedAt) Title() string     { return a.title }
+func (a byUpdatedAt) FieldName() string { return "UpdatedAt" }
+func (a byUpdatedAt) Name(i int) string { return a.data[i].displayName(a.fullname) }
+func (a byUpdatedAt) Field(i int) string {
+	return fmt.Sprintf("%v", truncTime(inLoc(a.data[i].UpdatedAt, a.loc), a.prec))
+}
+func (a byUpdatedAt) Repo(i int) dataStruct { return a.data[i] }
+func (a byUpdatedAt) Len() int              { return len(a.data) }
+func (a byUpdatedAt) Swap(i, j int)         { a.data[i], a.data[j] = a.data[j], a.data[i] }
 func (a byUpdatedAt) Less(i, j int) bool {
 	if a.sortasc {
 		return a.data[i].UpdatedAt.Before(a.data[j].UpdatedAt)
@@ -66,11 +266,18 @@ func (a byUpdatedAt) Less(i, j int) bool {
 // byPushedAt stuff for sort.Sort
 type byPushedAt ghStruct
 
-func (a byPushedAt) Title() string      { return a.title }
-func (a byPushedAt) Name(i int) string  { return a.data[i].Name }
-func (a byPushedAt) Field(i int) string { return fmt.Sprintf("%v", a.data[i].PushedAt) }
-func (a byPushedAt) Len() int           { return len(a.data) }
-func (a byPushedAt) Swap(i, j int)      { a.data[i], a.data[j] = a.data[j], a.data[i] }
+func (a byPushedAt) Title() string     { return a.title }
+func (a byPushedAt) FieldName() string { return "PushedAt" }
+func (a byPushedAt) Name(i int) string { return a.data[i].displayName(a.fullname) }
+func (a byPushedAt) Field(i int) string {
+	if a.data[i].PushedAt.IsZero() { // null pushed_at, never pushed
+		return noValue
+	}
+	return fmt.Sprintf("%v", truncTime(inLoc(a.data[i].PushedAt, a.loc), a.prec))
+}
+func (a byPushedAt) Repo(i int) dataStruct { return a.data[i] }
+func (a byPushedAt) Len() int              { return len(a.data) }
+func (a byPushedAt) Swap(i, j int)         { a.data[i], a.data[j] = a.data[j], a.data[i] }
 func (a byPushedAt) Less(i, j int) bool {
 	if a.sortasc {
 		return a.data[i].PushedAt.Before(a.data[j].PushedAt)
@@ -78,165 +285,3401 @@ func (a byPushedAt) Less(i, j int) bool {
 	return a.data[i].PushedAt.After(a.data[j].PushedAt)
 }
 
-func getData(urlname string) ([]dataStruct, error) {
-	var err error
-	var req *http.Request
-	var res *http.Response
-	var body []byte
-	var data, totData []dataStruct
-	page := 0
-	for {
-		page++
-		pagination := fmt.Sprintf("?page=%d", page)
+// bySubscribers stuff for sort.Sort
+type bySubscribers ghStruct
 
-		if req, err = http.NewRequest("GET", urlname+pagination, nil); err != nil {
-			return nil, err
-		}
+func (a bySubscribers) Title() string         { return a.title }
+func (a bySubscribers) FieldName() string     { return "SubscribersCount" }
+func (a bySubscribers) Name(i int) string     { return a.data[i].displayName(a.fullname) }
+func (a bySubscribers) Field(i int) string    { return fmt.Sprintf("%d", a.data[i].SubscribersCount) }
+func (a bySubscribers) Repo(i int) dataStruct { return a.data[i] }
+func (a bySubscribers) Len() int              { return len(a.data) }
+func (a bySubscribers) Swap(i, j int)         { a.data[i], a.data[j] = a.data[j], a.data[i] }
+func (a bySubscribers) Less(i, j int) bool {
+	if a.sortasc {
+		return a.data[i].SubscribersCount < a.data[j].SubscribersCount
+	}
+	return a.data[i].SubscribersCount > a.data[j].SubscribersCount
+}
 
-		req.Header.Add("Content-Type", `application/json; charset=utf-8`)
-		if res, err = http.DefaultClient.Do(req); err != nil {
-			return nil, err
-		}
-		defer func() { _ = res.Body.Close() }()
+// byIssueRatio stuff for sort.Sort
+type byIssueRatio ghStruct
 
-		if body, err = ioutil.ReadAll(res.Body); err != nil {
-			return nil, err
+func (a byIssueRatio) Title() string         { return a.title }
+func (a byIssueRatio) FieldName() string     { return "IssueRatio" }
+func (a byIssueRatio) Name(i int) string     { return a.data[i].displayName(a.fullname) }
+func (a byIssueRatio) Field(i int) string    { return fmt.Sprintf("%.2f", a.data[i].issueRatio()) }
+func (a byIssueRatio) Repo(i int) dataStruct { return a.data[i] }
+func (a byIssueRatio) Len() int              { return len(a.data) }
+func (a byIssueRatio) Swap(i, j int)         { a.data[i], a.data[j] = a.data[j], a.data[i] }
+func (a byIssueRatio) Less(i, j int) bool {
+	if a.sortasc {
+		return a.data[i].issueRatio() < a.data[j].issueRatio()
+	}
+	return a.data[i].issueRatio() > a.data[j].issueRatio()
+}
+
+// readBody - reads all of res.Body decompressing it when gzip encoded.
+func readBody(res *http.Response) ([]byte, error) {
+	wire := &countingReader{r: res.Body}
+	defer func() { atomic.AddInt64(&apiStats.wireBytes, wire.n) }()
+	if !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return ioutil.ReadAll(wire)
+	}
+	gz, err := gzip.NewReader(wire)
+	if err != nil {
+		return nil, fmt.Errorf("gzip response: %v", err)
+	}
+	defer func() { _ = gz.Close() }()
+	return ioutil.ReadAll(gz)
+}
+
+// countingReader - an io.Reader counting the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// apiStats - totals of the api requests of a run, logged when verbose,
+// updated atomically so requests may overlap.
+var apiStats struct {
+	requests  int64
+	wireBytes int64 // response body bytes as received, compressed or not
+	bodyBytes int64 // response body bytes after decompression
+}
+
+// humanBytes - returns n bytes formatted with a binary unit e.g. 1.5KiB.
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// rateLimitError - returned when GitHub refuses a request because of a rate
+// limit. Secondary (abuse) limits are hit by requesting too fast rather than
+// too many times and clear by slowing down, see -pagedelay.
+type rateLimitError struct {
+	secondary  bool
+	status     string
+	reset      time.Time     // when the primary limit resets, zero if unknown
+	retryAfter time.Duration // wait advised for the secondary limit, 0 if unknown
+}
+
+func (e *rateLimitError) Error() string {
+	if e.secondary {
+		msg := "secondary rate limit exceeded status:" + e.status
+		if e.retryAfter > 0 {
+			msg += fmt.Sprintf(" retryAfter:%v", e.retryAfter)
 		}
+		return msg
+	}
+	msg := "API rate limit exceeded status:" + e.status
+	if !e.reset.IsZero() {
+		msg += fmt.Sprintf(" reset:%v", e.reset)
+	}
+	return msg
+}
 
-		//fmt.Printf("%s\n", strings.Join(strings.Split(string(body), ","), "\n"))
-		if err = json.Unmarshal(body, &data); err != nil {
-			const rateErr = "API rate limit exceeded"
-			if strings.Contains(string(body), rateErr) {
-				return nil, fmt.Errorf("json.Unmarshal failed likely because of:%q jsonErr:%q", rateErr, err)
-			}
-			return nil, err
+// checkRateLimit - returns a *rateLimitError when res and its body show a
+// primary or secondary rate limit refusal otherwise nil.
+func checkRateLimit(res *http.Response, body []byte) error {
+	if res.StatusCode != http.StatusForbidden && res.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	if bytes.Contains(bytes.ToLower(body), []byte("secondary rate limit")) ||
+		bytes.Contains(bytes.ToLower(body), []byte("abuse detection")) {
+		e := &rateLimitError{secondary: true, status: res.Status}
+		if secs, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+			e.retryAfter = time.Duration(secs) * time.Second
+		}
+		return e
+	}
+	if res.Header.Get("X-RateLimit-Remaining") == "0" || bytes.Contains(body, []byte("API rate limit exceeded")) {
+		e := &rateLimitError{status: res.Status}
+		if secs, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			e.reset = time.Unix(secs, 0)
 		}
+		return e
+	}
+	return nil
+}
 
-		totData = append(totData, data...)
+// apiToken - token sent with api requests when not empty, a personal access
+// or installation token, minted from -appid when given else from -tokenfile
+// when given else from tokenEnv.
+var apiToken string
 
-		link := res.Header.Get("Link")
-		//fmt.Printf("Link:%v\n", res.Header.Get("Link"))
+// readTokenFile - returns the token in file with surrounding whitespace trimmed.
+func readTokenFile(file string) (string, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("%s is empty", file)
+	}
+	return token, nil
+}
+
+// tokenEnv - environment variable holding a GitHub token, needed for team
+// repos and private repos and raising the rate limit.
+const tokenEnv = "GITHUB_TOKEN"
+
+// apiBase - base url of the GitHub api.
+const apiBase = "https://api.github.com"
 
-		if !strings.Contains(link, `rel="next"`) {
-			return totData, nil
+// redirectPolicy - returns an http.Client CheckRedirect following up to max
+// redirects, 0 follows none, logging each when verbose since a redirect
+// usually means a renamed user/org or repo.
+func redirectPolicy(max int, verbose int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if max == 0 {
+			return http.ErrUseLastResponse
 		}
+		if len(via) > max {
+			return fmt.Errorf("stopped after %d redirects, see -maxredirects", max)
+		}
+		if verbose > 0 {
+			log.Printf("%s: redirected to %s\n", via[len(via)-1].URL, req.URL)
+		}
+		return nil
 	}
 }
 
-type sortType uint16
+// apiClient - client used for every request, built by newAPIClient in main.
+var apiClient = http.DefaultClient
 
-// sortType values
+// defaults for newAPIClient, api.github.com is one host so the per host idle
+// limit matters most, the transport's default of 2 forces reconnects when
+// requests overlap e.g. subscriber fetches.
 const (
-	sbyUpdatedAt sortType = 1 << iota
-	sbyPushedAt
-	sascending
-	sdefault = sbyUpdatedAt
+	defMaxIdlePerHost = 10
+	defKeepAlive      = 30 * time.Second
+	defMaxRedirects   = 5
 )
 
-// gitHubReposReportSummary - generates a summary for a given github url that
-// includes: totOpenIssues, mostWatchersRepo and a sorted list of repos by sortType
-// - urlname - name of github url for getting repos info
-// - writer  - io.Writer to generate output too.
-// - sorttype - see sortType values
-func gitHubReposReportSummary(urlname string, writer io.Writer, sortby sortType) error {
-	reportName := "GitHubReposReportSummary"
+// newAPIClient - returns a client keeping up to maxIdlePerHost idle
+// connections per host and sending tcp keep-alive probes every keepAlive,
+// a negative keepAlive disables keep-alives so each request reconnects.
+func newAPIClient(maxIdlePerHost int, keepAlive time.Duration, tlsConf *tls.Config) *http.Client {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.MaxIdleConnsPerHost = maxIdlePerHost
+	if tr.MaxIdleConns < maxIdlePerHost {
+		tr.MaxIdleConns = maxIdlePerHost
+	}
+	tr.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: keepAlive}).DialContext
+	tr.DisableKeepAlives = keepAlive < 0
+	if tlsConf != nil {
+		tr.TLSClientConfig = tlsConf
+	}
+	return &http.Client{Transport: tr}
+}
 
-	data, err := getData(urlname)
+// tlsConfig - returns the tls config trusting the pem certificates in
+// cacert besides the system ones, or skipping verification when insecure,
+// nil when neither is asked for.
+func tlsConfig(cacert string, insecure bool) (*tls.Config, error) {
+	if insecure {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+	if cacert == "" {
+		return nil, nil
+	}
+	pem, err := ioutil.ReadFile(cacert)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
 	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no pem certificates in %s", cacert)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
 
-	totOpenIssues := 0
-	maxWatchers := 0
-	maxWatchersName := "<NONE>"
-	for _, v := range data {
-		totOpenIssues += v.OpenIssuesCount
-		if v.WatchersCount < 0 {
-			return fmt.Errorf("WatchersCount is negative! %v", v.String())
+// teamURL - returns the team repos url of team given as org/team.
+func teamURL(team string) (string, error) {
+	parts := strings.Split(team, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("team %q not of the form org/team", team)
+	}
+	return apiBase + "/orgs/" + url.PathEscape(parts[0]) + "/teams/" + url.PathEscape(parts[1]) + "/repos", nil
+}
+
+// apiStatusError - returned for api responses with a non 2xx status.
+type apiStatusError struct {
+	code     int
+	status   string
+	message  string // GitHub's message from the body if any
+	location string // redirect target of 3xx statuses not followed
+}
+
+func (e *apiStatusError) Error() string {
+	msg := "status:" + e.status
+	if e.message != "" {
+		msg += fmt.Sprintf(" message:%q", e.message)
+	}
+	if e.location != "" {
+		msg += " location:" + e.location + " (renamed user/org? redirects aren't followed with -noredirect)"
+	}
+	if e.code == http.StatusNotFound {
+		msg += " (nonexistent user/org/team or private without a token having access, see " + tokenEnv + ")"
+	}
+	return msg
+}
+
+// apiGet - GETs urlname returning the response, whose body is already
+// consumed and closed, and the body contents. With -ratewait a request
+// refused by the primary rate limit is retried after the reset, and one
+// refused by a secondary limit after its Retry-After.
+func apiGet(ctx context.Context, urlname string) (*http.Response, []byte, error) {
+	for {
+		res, body, err := apiGetOnce(ctx, urlname)
+		var rle *rateLimitError
+		if errors.As(err, &rle) && !rle.secondary && time.Until(rle.reset) > 0 && apiRate.holding() {
+			// refused, hold everything for the reset and try again.
+			apiRate.hold(rle.reset)
+			continue
 		}
-		if v.WatchersCount > maxWatchers {
-			maxWatchersName = v.Name
-			maxWatchers = v.WatchersCount
-		} else if v.WatchersCount > 0 && v.WatchersCount == maxWatchers {
-			maxWatchersName += "," + v.Name
+		if errors.As(err, &rle) && rle.secondary && rle.retryAfter > 0 && apiRate.holding() {
+			// slow down as advised, holding everything for the Retry-After.
+			apiRate.hold(time.Now().Add(rle.retryAfter))
+			continue
 		}
+		return res, body, err
+	}
+}
+
+// apiGetOnce - a single apiGet request, held by apiRate while limited.
+func apiGetOnce(ctx context.Context, urlname string) (*http.Response, []byte, error) {
+	if err := apiRate.await(ctx); err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", urlname, nil)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	fmt.Fprintf(writer, "%s:\nPublic accessible info for %s\n", reportName, urlname)
-	fmt.Fprintf(writer, "totOpenIssues:%d mostWatchersRepo:%s [maxWatchers:%d]\n",
-		totOpenIssues, maxWatchersName, maxWatchers)
+	req.Header.Add("Content-Type", `application/json; charset=utf-8`)
+	// mercy preview includes topics in repo objects.
+	req.Header.Set("Accept", "application/vnd.github.mercy-preview+json")
+	// setting Accept-Encoding disables the transport's transparent
+	// decompression so readBody must handle gzip itself.
+	req.Header.Set("Accept-Encoding", "gzip")
+	if apiToken != "" {
+		req.Header.Set("Authorization", "token "+apiToken)
+	}
+	res, err := apiClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
 
-	var bdata interface2
-	asc := sortby&sascending > 0
-	asctxt := "ascending"
-	if !asc {
-		asctxt = "descending"
+	atomic.AddInt64(&apiStats.requests, 1)
+	apiRate.observe(res)
+	body, err := readBody(res)
+	atomic.AddInt64(&apiStats.bodyBytes, int64(len(body)))
+	if err != nil {
+		return nil, nil, err
 	}
-	switch {
-	case sortby&sbyPushedAt > 0:
-		bdata = byPushedAt{"byPushedAt " + asctxt, asc, data}
-	default:
-		fallthrough
-	case sortby&sbyUpdatedAt > 0:
-		bdata = byUpdatedAt{"byUpdatedAt " + asctxt, asc, data}
+	if err = checkRateLimit(res, body); err != nil {
+		return nil, nil, err
 	}
-	sort.Sort(bdata)
-	fmt.Fprintf(writer, "Repos [%d] sorted by %s:\n", bdata.Len(), bdata.Title())
-	for i := 0; i < bdata.Len(); i++ {
-		fmt.Fprintf(writer, "i:%2d %v %s\n", i, bdata.Field(i), bdata.Name(i))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		e := &apiStatusError{code: res.StatusCode, status: res.Status, location: res.Header.Get("Location")}
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &msg) == nil {
+			e.message = msg.Message
+		}
+		return nil, nil, e
 	}
-	fmt.Fprintf(writer, "<endOfReport: %s>\n", reportName)
+	return res, body, nil
+}
 
+// apiUnmarshal - json.Unmarshal of body into v, mentioning a rate limit
+// failure in the error when body has one.
+func apiUnmarshal(body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		const rateErr = "API rate limit exceeded"
+		if strings.Contains(string(body), rateErr) {
+			return fmt.Errorf("json.Unmarshal failed likely because of:%q jsonErr:%q", rateErr, err)
+		}
+		return err
+	}
 	return nil
 }
 
-type flagsStruct struct {
-	showVersion bool
-	verbose     int
-	ghurl       string
-	ascending   bool
-	bypushedat  bool
+// errNotRepoList - a page body is a json object other than a repo.
+var errNotRepoList = errors.New("response is a json object not a list of repos, -ghurl needs a repos list url e.g. " + apiBase + "/orgs/NAME/repos")
+
+// isJSONObject - reports whether body is a json object rather than an array.
+func isJSONObject(body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && trimmed[0] == '{'
 }
 
-// example of organization github api repos url : "https://api.github.com/orgs/gorilla/repos"
-// example of users        github api repos url: "https://api.github.com/users/phcurtis/repos"
+// unmarshalRepos - apiUnmarshal of a page of repos into data. A single repo
+// object, as from a /repos/owner/name url, is taken as a page of one repo,
+// any other object errors saying a list url is needed.
+func unmarshalRepos(body []byte, data *[]dataStruct) error {
+	if !isJSONObject(body) {
+		return apiUnmarshal(body, data)
+	}
+	var d dataStruct
+	if err := apiUnmarshal(body, &d); err != nil {
+		return err
+	}
+	if d.FullName == "" {
+		return errNotRepoList
+	}
+	*data = append(*data, d)
+	return nil
+}
 
-var flags = flagsStruct{}
+// repoObjects - the unparsed repo objects of a page body, normalized as
+// unmarshalRepos does, a single repo object being a page of one.
+func repoObjects(body []byte) ([]json.RawMessage, error) {
+	var objs []json.RawMessage
+	if !isJSONObject(body) {
+		return objs, apiUnmarshal(body, &objs)
+	}
+	var d struct {
+		FullName string `json:"full_name"`
+	}
+	if err := apiUnmarshal(body, &d); err != nil {
+		return nil, err
+	}
+	if d.FullName == "" {
+		return nil, errNotRepoList
+	}
+	return append(objs, bytes.TrimSpace(body)), nil
+}
 
-const ghurlDef = "https://api.github.com/users/phcurtis/repos"
+// fetchOpts - options controlling how repos info is fetched.
+type fetchOpts struct {
+	perPage    int           // repos per page request, 0 uses the api default
+	demo       bool          // use the embedded demo dataset instead of the api
+	startPage  int           // page to start pagination at, <= 1 starts at the first page
+	verbose    int           // verbose level, >0 logs each fetched page
+	pageDelay  time.Duration // wait between page requests
+	strict     bool          // error when repo objects lack requiredFields
+	bestEffort bool          // skip pages that fail to parse instead of failing
+}
 
-func init() {
-	flag.StringVar(&flags.ghurl, "ghurl", ghurlDef, "github url for getting repos info")
-	flag.BoolVar(&flags.showVersion, "version", false, "show version")
-	flag.IntVar(&flags.verbose, "verbose", 0, "verbose level")
-	flag.BoolVar(&flags.ascending, "ascending", false, "sort ascending")
-	flag.BoolVar(&flags.bypushedat, "bypushedat", false, "sort bypushedat field")
+// pageWait - waits fo.pageDelay before a request following another,
+// returning early with ctx's error when it's done.
+func pageWait(ctx context.Context, fo fetchOpts) error {
+	if fo.pageDelay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(fo.pageDelay):
+		return nil
+	}
 }
 
-func main() {
-	flag.Parse()
-	if flag.NArg() > 0 {
-		fmt.Fprintf(os.Stderr, "unrecognized %v\nUsage of ./%s:\n",
-			flag.Args(), filepath.Base(os.Args[0]))
-		flag.PrintDefaults()
-		os.Exit(1)
+// requiredFields - repo object fields -strict requires to be present.
+var requiredFields = []string{"name", "created_at", "updated_at", "pushed_at"}
+
+// checkRequired - returns an error naming the first repo object of the page
+// body missing one of requiredFields or having a null/empty name.
+func checkRequired(body []byte) error {
+	raws, err := repoObjects(body)
+	if err != nil {
+		return err
 	}
-	if flags.verbose > 0 {
-		fmt.Printf("%v version:%s\n", os.Args, version)
+	for i, raw := range raws {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return err
+		}
+		var name string
+		_ = json.Unmarshal(obj["name"], &name)
+		for _, f := range requiredFields {
+			if _, ok := obj[f]; !ok {
+				return fmt.Errorf("strict: repo index:%d name:%q missing field %q", i, name, f)
+			}
+		}
+		if name == "" {
+			return fmt.Errorf("strict: repo index:%d has a null or empty name", i)
+		}
 	}
-	if flags.showVersion {
-		fmt.Printf("./%s version=%s\n", filepath.Base(os.Args[0]), version)
+	return nil
+}
+
+//go:embed demo/repos.json
+var demoFS embed.FS
+
+// demoName - name of the embedded demo dataset, used as its url.
+const demoName = "demo/repos.json"
+
+// getDemoData - returns the embedded demo dataset.
+func getDemoData() ([]dataStruct, error) {
+	body, err := demoFS.ReadFile(demoName)
+	if err != nil {
+		return nil, err
 	}
-	var stype sortType
-	if flags.ascending {
-		stype = sascending
+	var data []dataStruct
+	if err = json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("%s: %v", demoName, err)
 	}
-	if flags.bypushedat {
-		stype |= sbyPushedAt
+	return data, nil
+}
+
+// defPerPage - the api's page size when per_page is not given.
+const defPerPage = 30
+
+// parseLinks - returns the urls of a Link header keyed by their rel and the
+// number of malformed entries that were skipped.
+func parseLinks(link string) (map[string]string, int) {
+	links := make(map[string]string)
+	bad := 0
+	if strings.TrimSpace(link) == "" {
+		return links, 0
+	}
+	for _, entry := range strings.Split(link, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ";")
+		u := strings.TrimSpace(parts[0])
+		if len(u) < 2 || !strings.HasPrefix(u, "<") || !strings.HasSuffix(u, ">") {
+			bad++
+			continue
+		}
+		found := false
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "rel=") {
+				for _, rel := range strings.Fields(strings.Trim(param[len("rel="):], `"`)) {
+					links[rel] = u[1 : len(u)-1]
+					found = true
+				}
+			}
+		}
+		if !found {
+			bad++
+		}
+	}
+	return links, bad
+}
+
+// pageNum - returns the page query parameter of urlname or 0 if absent.
+func pageNum(urlname string) int {
+	u, err := url.Parse(urlname)
+	if err != nil {
+		return 0
+	}
+	page, err := strconv.Atoi(u.Query().Get("page"))
+	if err != nil {
+		return 0
 	}
+	return page
+}
 
-	err := gitHubReposReportSummary(flags.ghurl, os.Stdout, stype)
+// resolveURL - returns ref resolved against base, so relative Link urls work.
+func resolveURL(base, ref string) (string, error) {
+	b, err := url.Parse(base)
 	if err != nil {
-		log.Fatalf("%s: err:%v\n", os.Args, err)
+		return "", err
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(r).String(), nil
+}
+
+// pageURL - returns urlname with the pagination query parameters added.
+func pageURL(urlname string, page, perPage int) string {
+	sep := "?"
+	if strings.Contains(urlname, "?") {
+		sep = "&"
+	}
+	pagination := fmt.Sprintf("%spage=%d", sep, page)
+	if perPage > 0 {
+		pagination += fmt.Sprintf("&per_page=%d", perPage)
+	}
+	return urlname + pagination
+}
+
+// pageHandler - handles the body of page of a paginated request, lastPage
+// is the page number of the Link rel="last" url or 0 when unknown. It
+// returns the number of repos in the page.
+type pageHandler func(page int, body []byte, lastPage int) (int, error)
+
+// paginate - GETs each page of urlname passing its body to handle.
+func paginate(ctx context.Context, urlname string, fo fetchOpts, handle pageHandler) error {
+	var err error
+	var res *http.Response
+	var body []byte
+	page := 0
+	if fo.startPage > 1 {
+		page = fo.startPage - 1
+	}
+	lastFetched := func() string {
+		if page-1 < fo.startPage || page <= 1 {
+			return "none"
+		}
+		return strconv.Itoa(page - 1)
+	}
+	// only the first page url is built, after that the Link next urls are
+	// followed as is so endpoints paginating by cursor work too.
+	pageurl := pageURL(urlname, page+1, fo.perPage)
+	visited := make(map[string]bool)
+	for {
+		page++
+		if page > fo.startPage && page > 1 {
+			if err := pageWait(ctx, fo); err != nil {
+				return err
+			}
+		}
+
+		visited[pageurl] = true
+		if res, body, err = apiGet(ctx, pageurl); err != nil {
+			return fmt.Errorf("page:%d lastFetchedPage:%s err:%w", page, lastFetched(), err)
+		}
+
+		links, bad := parseLinks(res.Header.Get("Link"))
+		//fmt.Printf("Link:%v\n", res.Header.Get("Link"))
+		if bad > 0 && fo.verbose > 0 {
+			log.Printf("%s: warning page:%d skipped %d malformed Link header entries: %q\n",
+				urlname, page, bad, res.Header.Get("Link"))
+		}
+
+		n, err := handle(page, body, pageNum(links["last"]))
+		if err != nil {
+			return fmt.Errorf("page:%d lastFetchedPage:%s err:%w", page, lastFetched(), err)
+		}
+		if fo.verbose > 0 {
+			log.Printf("%s: fetched page:%d repos:%d\n", urlname, page, n)
+		}
+
+		next, ok := links["next"]
+		if !ok {
+			return nil
+		}
+		if next, err = resolveURL(pageurl, next); err != nil {
+			if fo.verbose > 0 {
+				log.Printf("%s: warning page:%d malformed next link, stopping pagination: %v\n", urlname, page, err)
+			}
+			return nil
+		}
+		if np := pageNum(next); visited[next] || (np != 0 && np <= page && pageNum(pageurl) != 0) {
+			// a next link that doesn't advance would spin forever.
+			if fo.verbose > 0 {
+				log.Printf("%s: warning page:%d next link %q doesn't advance, stopping pagination\n",
+					urlname, page, next)
+			}
+			return nil
+		}
+		pageurl = next
+	}
+}
+
+// getData - returns the repos of every page of urlname and, with
+// fo.bestEffort, the pages skipped because they failed to parse.
+func getData(ctx context.Context, urlname string, fo fetchOpts) ([]dataStruct, []string, error) {
+	var totData []dataStruct
+	var skipped []string
+	perPage := fo.perPage
+	if perPage <= 0 {
+		perPage = defPerPage
+	}
+	err := paginate(ctx, urlname, fo, func(page int, body []byte, lastPage int) (int, error) {
+		//fmt.Printf("%s\n", strings.Join(strings.Split(string(body), ","), "\n"))
+		// a fresh slice per page, reusing one would let json.Unmarshal
+		// overwrite the backing arrays of slice fields already in totData.
+		// ReadAll+Unmarshal measured faster and lighter than json.Decoder
+		// (which buffers the whole array anyway) so pages aren't streamed,
+		// see BenchmarkUnmarshalRepos and BenchmarkDecodeRepos.
+		var data []dataStruct
+		if err := unmarshalRepos(body, &data); err != nil {
+			if !fo.bestEffort {
+				return 0, err
+			}
+			log.Printf("%s: warning skipping page:%d err:%v\n", urlname, page, err)
+			skipped = append(skipped, fmt.Sprintf("%s page:%d", urlname, page))
+			return 0, nil
+		}
+		if fo.strict {
+			if err := checkRequired(body); err != nil {
+				return 0, err
+			}
+		}
+		if totData == nil && lastPage > page {
+			// pre-size from the last page to avoid repeated growth on large orgs.
+			totData = make([]dataStruct, 0, (lastPage-page+1)*perPage)
+		}
+		totData = append(totData, data...)
+		return len(data), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return totData, skipped, nil
+}
+
+// rawDump - writes the repo objects of every page of each of urlnames,
+// unparsed, as a single pretty printed json array. When verbose each page
+// is preceded by a // comment line naming it, which isn't valid json.
+// With fo.demo the embedded demo dataset is the one page dumped.
+func rawDump(ctx context.Context, urlnames []string, writer io.Writer, fo fetchOpts) error {
+	bw := bufio.NewWriter(writer)
+	fmt.Fprint(bw, "[")
+	first := true
+	if fo.demo {
+		urlnames = []string{demoName}
+	}
+	for _, urlname := range urlnames {
+		handle := func(page int, body []byte, lastPage int) (int, error) {
+			objs, err := repoObjects(body)
+			if err != nil {
+				return 0, err
+			}
+			for i, obj := range objs {
+				var ibuf bytes.Buffer
+				if err := json.Indent(&ibuf, obj, "  ", "  "); err != nil {
+					return 0, err
+				}
+				if !first {
+					fmt.Fprint(bw, ",")
+				}
+				first = false
+				if i == 0 && fo.verbose > 0 {
+					fmt.Fprintf(bw, "\n  // %s page:%d", urlname, page)
+				}
+				fmt.Fprint(bw, "\n  ")
+				_, _ = ibuf.WriteTo(bw)
+			}
+			return len(objs), nil
+		}
+		var err error
+		if fo.demo {
+			var body []byte
+			if body, err = demoFS.ReadFile(demoName); err == nil {
+				_, err = handle(1, body, 1)
+			}
+		} else {
+			err = paginate(ctx, urlname, fo, handle)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", urlname, err)
+		}
+	}
+	fmt.Fprint(bw, "\n]\n")
+	return bw.Flush()
+}
+
+// compareSources - writes a table comparing each of urlnames, one row per
+// source with its repo count, open issues and stars, then a total row.
+// The counts are of everything fetched, before any filtering.
+func compareSources(ctx context.Context, urlnames []string, writer io.Writer, fo fetchOpts) error {
+	width := len("total")
+	for _, urlname := range urlnames {
+		if len(urlname) > width {
+			width = len(urlname)
+		}
+	}
+	bw := bufio.NewWriter(writer)
+	fmt.Fprintf(bw, "%-*s %8s %11s %10s\n", width, "source", "repos", "open_issues", "stars")
+	var repos, issues, stars int
+	for _, urlname := range urlnames {
+		data, _, err := getAllData(ctx, []string{urlname}, fo)
+		if err != nil {
+			return err
+		}
+		acc := accumulate(data, summaryMetrics, defTieBreak)
+		fmt.Fprintf(bw, "%-*s %8d %11d %10d\n", width, urlname, len(data), acc["open_issues"].total, acc["stars"].total)
+		repos += len(data)
+		issues += acc["open_issues"].total
+		stars += acc["stars"].total
+	}
+	if len(urlnames) > 1 {
+		fmt.Fprintf(bw, "%-*s %8d %11d %10d\n", width, "total", repos, issues, stars)
+	}
+	return bw.Flush()
+}
+
+// getAllData - returns the combined getData results of each of urlnames.
+func getAllData(ctx context.Context, urlnames []string, fo fetchOpts) ([]dataStruct, []string, error) {
+	if fo.demo {
+		data, err := getDemoData()
+		return data, nil, err
+	}
+	var totData []dataStruct
+	var totSkipped []string
+	for _, urlname := range urlnames {
+		data, skipped, err := getData(ctx, urlname, fo)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", urlname, err)
+		}
+		totData = append(totData, data...)
+		totSkipped = append(totSkipped, skipped...)
+	}
+	return totData, totSkipped, nil
+}
+
+// orgInfo - org metadata from the org endpoint.
+type orgInfo struct {
+	Login       string `json:"login"`
+	Name        string `json:"name"`
+	PublicRepos int    `json:"public_repos"`
+	Blog        string `json:"blog"`
+	Location    string `json:"location"`
+	allRepos    bool   // fetched for a url listing all the org's repos, not a team's
+}
+
+// orgURL - returns the org endpoint for an org repos url, e.g.
+// https://api.github.com/orgs/gorilla for https://api.github.com/orgs/gorilla/repos,
+// or "" when urlname isn't an org url.
+func orgURL(urlname string) string {
+	u, err := url.Parse(urlname)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "orgs" {
+			u.Path = "/" + strings.Join(parts[:i+2], "/")
+			u.RawQuery = ""
+			return u.String()
+		}
+	}
+	return ""
+}
+
+// orgReposURL - returns whether urlname lists all of an org's repos, e.g.
+// https://api.github.com/orgs/gorilla/repos, rather than a team's.
+func orgReposURL(urlname string) bool {
+	u, err := url.Parse(urlname)
+	if err != nil {
+		return false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	n := len(parts)
+	return n >= 3 && parts[n-3] == "orgs" && parts[n-1] == "repos"
+}
+
+// repoCountWarnings - returns a warning for each of orgs listed in full whose
+// public_repos differs by more than 5%, and at least 1, from the public repos
+// of it in data, which often means pagination stopped early. Private repos
+// aren't in public_repos and forks are, so data is compared as fetched.
+// Team listings are only some of the org's repos so aren't compared.
+func repoCountWarnings(orgs []orgInfo, data []dataStruct) []string {
+	var warnings []string
+	for _, org := range orgs {
+		if !org.allRepos {
+			continue
+		}
+		fetched := 0
+		for _, v := range data {
+			if !v.Private && strings.EqualFold(v.Owner.Login, org.Login) {
+				fetched++
+			}
+		}
+		diff := fetched - org.PublicRepos
+		if diff < 0 {
+			diff = -diff
+		}
+		tolerance := org.PublicRepos / 20
+		if tolerance < 1 {
+			tolerance = 1
+		}
+		if diff > tolerance {
+			warnings = append(warnings, fmt.Sprintf("org %s reports %d public repos but %d were fetched, pagination may have stopped early",
+				org.Login, org.PublicRepos, fetched))
+		}
+	}
+	return warnings
+}
+
+// getOrgInfo - returns the orgInfo of each org url in urlnames, other urls
+// are skipped.
+func getOrgInfo(ctx context.Context, urlnames []string) ([]orgInfo, error) {
+	var orgs []orgInfo
+	for _, urlname := range urlnames {
+		ourl := orgURL(urlname)
+		if ourl == "" {
+			continue
+		}
+		_, body, err := apiGet(ctx, ourl)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ourl, err)
+		}
+		var org orgInfo
+		if err = apiUnmarshal(body, &org); err != nil {
+			return nil, fmt.Errorf("%s: %w", ourl, err)
+		}
+		org.allRepos = orgReposURL(urlname)
+		orgs = append(orgs, org)
+	}
+	return orgs, nil
+}
+
+// getSubscribers - fills in SubscribersCount of each element of data from
+// its repo endpoint, since list endpoints do not return subscribers_count.
+func getSubscribers(ctx context.Context, data []dataStruct) error {
+	for i := range data {
+		if data[i].URL == "" {
+			return fmt.Errorf("no url to get subscribers_count for repo:%s", data[i].Name)
+		}
+		_, body, err := apiGet(ctx, data[i].URL)
+		if err != nil {
+			return err
+		}
+		var repo dataStruct
+		if err = apiUnmarshal(body, &repo); err != nil {
+			return fmt.Errorf("repo:%s err:%w", data[i].Name, err)
+		}
+		data[i].SubscribersCount = repo.SubscribersCount
+	}
+	return nil
+}
+
+// getReleases - sets the Releases count of each of data requesting one
+// release per page so the Link last page number is the count.
+func getReleases(ctx context.Context, data []dataStruct, fo fetchOpts) error {
+	for i := range data {
+		if data[i].URL == "" {
+			return fmt.Errorf("no url to get releases for repo:%s", data[i].Name)
+		}
+		if i > 0 {
+			if err := pageWait(ctx, fo); err != nil {
+				return err
+			}
+		}
+		res, body, err := apiGet(ctx, pageURL(data[i].URL+"/releases", 1, 1))
+		if err != nil {
+			return fmt.Errorf("repo:%s releases err:%w", data[i].Name, err)
+		}
+		links, _ := parseLinks(res.Header.Get("Link"))
+		if n := pageNum(links["last"]); n > 0 {
+			data[i].Releases = &n
+			continue
+		}
+		var releases []json.RawMessage
+		if err = apiUnmarshal(body, &releases); err != nil {
+			return fmt.Errorf("repo:%s releases err:%w", data[i].Name, err)
+		}
+		n := len(releases)
+		data[i].Releases = &n
+	}
+	return nil
+}
+
+// getProtection - sets Protected of each of data from its default branch's
+// protection endpoint, which needs a token with admin access, a 404 is no
+// protection (or no branch yet).
+func getProtection(ctx context.Context, data []dataStruct, fo fetchOpts) error {
+	for i := range data {
+		if data[i].URL == "" || data[i].DefaultBranch == "" {
+			return fmt.Errorf("no url or default branch to get protection for repo:%s", data[i].Name)
+		}
+		if i > 0 {
+			if err := pageWait(ctx, fo); err != nil {
+				return err
+			}
+		}
+		protected := true
+		_, _, err := apiGet(ctx, data[i].URL+"/branches/"+url.PathEscape(data[i].DefaultBranch)+"/protection")
+		var se *apiStatusError
+		switch {
+		case errors.As(err, &se) && se.code == http.StatusNotFound:
+			protected = false
+		case errors.As(err, &se) && se.code == http.StatusForbidden:
+			return fmt.Errorf("repo:%s protection err:%w (needs a token with admin access)", data[i].Name, err)
+		case err != nil:
+			return fmt.Errorf("repo:%s protection err:%w", data[i].Name, err)
+		}
+		data[i].Protected = &protected
+	}
+	return nil
+}
+
+// verifyStars - re-gets stargazers_count from the repo endpoint for the n
+// repos of data with the most watchers (stars), correcting WatchersCount
+// where the list endpoint disagreed, and returns how many were corrected.
+func verifyStars(ctx context.Context, data []dataStruct, n int, verbose int) (int, error) {
+	idx := make([]int, len(data))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return data[idx[a]].WatchersCount > data[idx[b]].WatchersCount })
+	if n > len(idx) {
+		n = len(idx)
+	}
+	fixed := 0
+	for _, i := range idx[:n] {
+		if data[i].URL == "" {
+			return fixed, fmt.Errorf("no url to verify stars for repo:%s", data[i].Name)
+		}
+		_, body, err := apiGet(ctx, data[i].URL)
+		if err != nil {
+			return fixed, err
+		}
+		var repo struct {
+			StargazersCount int `json:"stargazers_count"`
+		}
+		if err = apiUnmarshal(body, &repo); err != nil {
+			return fixed, fmt.Errorf("repo:%s err:%w", data[i].Name, err)
+		}
+		if repo.StargazersCount != data[i].WatchersCount {
+			if verbose > 0 {
+				log.Printf("repo:%s list stars:%d repo endpoint stars:%d, using the latter\n",
+					data[i].Name, data[i].WatchersCount, repo.StargazersCount)
+			}
+			data[i].WatchersCount = repo.StargazersCount
+			fixed++
+		}
+	}
+	return fixed, nil
+}
+
+// hasTopic - returns whether the repo has topic.
+func (d dataStruct) hasTopic(topic string) bool {
+	for _, t := range d.Topics {
+		if strings.EqualFold(t, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLanguage - returns whether the repo's primary language is one of languages.
+func (d dataStruct) hasLanguage(languages []string) bool {
+	for _, l := range languages {
+		if strings.EqualFold(d.Language, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAllTopics - returns whether the repo has every one of topics.
+func (d dataStruct) hasAllTopics(topics []string) bool {
+	for _, t := range topics {
+		if !d.hasTopic(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAnyTopic - returns whether the repo has at least one of topics.
+func (d dataStruct) hasAnyTopic(topics []string) bool {
+	for _, t := range topics {
+		if d.hasTopic(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList - returns the trimmed non empty elements of comma separated list.
+func splitList(list string) []string {
+	var out []string
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// nameList - returns the names of the comma separated list plus those of
+// file, one per line with blank lines and # comments ignored, file "" reads none.
+func nameList(list, file string) ([]string, error) {
+	names := splitList(list)
+	if file == "" {
+		return names, nil
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, line)
+		}
+	}
+	return names, nil
+}
+
+// mergeStrategies - the -mergestrategy choices of which copy of a repo seen
+// more than once -dedup keeps, each returns whether dup should replace kept.
+var mergeStrategies = map[string]func(kept, dup dataStruct) bool{
+	"newest": func(kept, dup dataStruct) bool { return dup.PushedAt.After(kept.PushedAt) },
+	"stars":  func(kept, dup dataStruct) bool { return dup.WatchersCount > kept.WatchersCount },
+	"first":  func(kept, dup dataStruct) bool { return false },
+}
+
+// defMergeStrategy - the default -mergestrategy.
+const defMergeStrategy = "newest"
+
+// mergeStrategyNames - returns the sorted names of mergeStrategies.
+func mergeStrategyNames() []string {
+	var names []string
+	for k := range mergeStrategies {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dedupByID - returns data without the repeats of repos already seen by id
+// and how many were dropped, repos without an id are kept. A repo stays at
+// its first position holding the copy replace prefers, see mergeStrategies.
+func dedupByID(data []dataStruct, replace func(kept, dup dataStruct) bool) ([]dataStruct, int) {
+	seen := make(map[int64]int)
+	var out []dataStruct
+	for _, d := range data {
+		if d.ID == 0 {
+			out = append(out, d)
+			continue
+		}
+		if i, ok := seen[d.ID]; ok {
+			if replace(out[i], d) {
+				out[i] = d
+			}
+			continue
+		}
+		seen[d.ID] = len(out)
+		out = append(out, d)
+	}
+	return out, len(data) - len(out)
+}
+
+// redactPrivate - replaces the names and urls of the private repos of data
+// with private-repo-N placeholders, numbered in data order, leaving metrics as
+// is. Descriptions and topics naming the repo are dropped too.
+func redactPrivate(data []dataStruct) {
+	n := 0
+	for i := range data {
+		if !data[i].Private {
+			continue
+		}
+		n++
+		d := &data[i]
+		name := strings.ToLower(d.Name)
+		var topics []string
+		for _, t := range d.Topics {
+			if name == "" || !strings.Contains(strings.ToLower(t), name) {
+				topics = append(topics, t)
+			}
+		}
+		d.Topics = topics
+		d.Description = ""
+		d.Name = fmt.Sprintf("private-repo-%d", n)
+		if owner := d.owner(); owner != "" {
+			d.FullName = owner + "/" + d.Name
+		} else {
+			d.FullName = d.Name
+		}
+		d.URL, d.HTMLURL = "", ""
+		if d.MirrorURL != "" {
+			d.MirrorURL = "redacted"
+		}
+	}
+}
+
+// parseAge - returns the duration of age given in days e.g. 90d or as a
+// time.ParseDuration duration e.g. 36h.
+func parseAge(age string) (time.Duration, error) {
+	if strings.HasSuffix(age, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("age %q not a number of days e.g. 90d", age)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("age %q not a number of days e.g. 90d or a duration e.g. 36h", age)
+	}
+	return d, nil
+}
+
+// parseSize - returns the bytes of size, a number with an optional binary
+// unit K, M, G or T e.g. 1GB or 512MiB.
+func parseSize(size string) (int64, error) {
+	s := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(size), "B"), "I")
+	mult := int64(1)
+	if n := len(s); n > 0 {
+		if i := strings.IndexByte("KMGT", s[n-1]); i >= 0 {
+			mult = int64(1) << (10 * uint(i+1))
+			s = s[:n-1]
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("size %q not a number of bytes e.g. 1GB or 512MiB", size)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// hasString - returns whether list holds s.
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// filterData - returns the elements of data for which keep returns true.
+func filterData(data []dataStruct, keep func(dataStruct) bool) []dataStruct {
+	var out []dataStruct
+	for _, v := range data {
+		if keep(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+type sortType uint16
+
+// sortType values
+const (
+	sbyUpdatedAt sortType = 1 << iota
+	sbyPushedAt
+	sbySubscribers
+	sbyIssueRatio
+	sbyExpr
+	sascending
+	sdefault = sbyUpdatedAt
+)
+
+// extraFields - optional per repo columns selectable by -fields, formatted
+// per the report's opts.
+var extraFields = map[string]func(d dataStruct, opts reportOpts) string{
+	"topics":       func(d dataStruct, opts reportOpts) string { return strings.Join(d.Topics, ",") },
+	"language":     func(d dataStruct, opts reportOpts) string { return orNoValue(d.Language) },
+	"size":         func(d dataStruct, opts reportOpts) string { return humanBytes(d.Size * 1024) },
+	"updated":      func(d dataStruct, opts reportOpts) string { return fieldTime(d.UpdatedAt, opts) },
+	"pushed":       func(d dataStruct, opts reportOpts) string { return fieldTime(d.PushedAt, opts) },
+	"stars":        func(d dataStruct, opts reportOpts) string { return strconv.Itoa(d.WatchersCount) },
+	"subscribers":  func(d dataStruct, opts reportOpts) string { return strconv.Itoa(d.SubscribersCount) }, // true watchers, see -subscribers
+	"forks":        func(d dataStruct, opts reportOpts) string { return strconv.Itoa(d.ForksCount) },
+	"open_issues":  func(d dataStruct, opts reportOpts) string { return strconv.Itoa(d.OpenIssuesCount) },
+	"owner_type":   func(d dataStruct, opts reportOpts) string { return orNoValue(d.Owner.Type) },
+	"releases":     func(d dataStruct, opts reportOpts) string { return d.releasesTxt() },
+	"has_issues":   func(d dataStruct, opts reportOpts) string { return strconv.FormatBool(d.HasIssues) },
+	"has_wiki":     func(d dataStruct, opts reportOpts) string { return strconv.FormatBool(d.HasWiki) },
+	"has_projects": func(d dataStruct, opts reportOpts) string { return strconv.FormatBool(d.HasProjects) },
+	"license":      func(d dataStruct, opts reportOpts) string { return d.licenseID() },
+	"protected":    func(d dataStruct, opts reportOpts) string { return d.protectedTxt() },
+}
+
+// fieldTime - returns t in opts' -tz and -timeprecision as text timestamps
+// are, noValue when unset e.g. a never pushed repo's null pushed_at.
+func fieldTime(t time.Time, opts reportOpts) string {
+	if t.IsZero() {
+		return noValue
+	}
+	return fmt.Sprintf("%v", truncTime(inLoc(t, opts.loc), opts.timePrecision))
+}
+
+// metricsFields - the extraFields columns of -output metrics unless -fields
+// picks others, subscribers only with -subscribers as the repos list lacks it.
+func metricsFields(opts reportOpts) []string {
+	if opts.subscribers {
+		return []string{"updated", "pushed", "stars", "subscribers", "forks", "open_issues"}
+	}
+	return []string{"updated", "pushed", "stars", "forks", "open_issues"}
+}
+
+// prsSuffix - appended to open issue count labels with -issuesprs.
+const prsSuffix = "+prs"
+
+// fieldLabel - returns the column label of extraFields field f.
+func fieldLabel(f string, opts reportOpts) string {
+	if f == "open_issues" && opts.issuesPRs {
+		return f + prsSuffix
+	}
+	return f
+}
+
+// extraFieldNames - returns the sorted names of extraFields.
+func extraFieldNames() []string {
+	var names []string
+	for k := range extraFields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseFields - returns the comma separated extraFields names in fields,
+// erroring on unknown names.
+func parseFields(fields string) ([]string, error) {
+	if fields == "" {
+		return nil, nil
+	}
+	var out []string
+	for _, f := range strings.Split(fields, ",") {
+		f = strings.TrimSpace(f)
+		if _, ok := extraFields[f]; !ok {
+			return nil, fmt.Errorf("unknown field %q valid fields:%s", f, strings.Join(extraFieldNames(), ","))
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// wrapWidth - returns the -wrap width to use: width when > 0 else the
+// terminal width from $COLUMNS when set else 80.
+func wrapWidth(width int) int {
+	if width > 0 {
+		return width
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return 80
+}
+
+// wrapText - returns the words of s as lines of at most width runes, words
+// longer than width get a line of their own.
+func wrapText(s string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// truncate - returns s cut to at most width runes ending in "..." when cut,
+// width <= 0 leaves s as is.
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	if width <= 3 {
+		return string(r[:width])
+	}
+	return string(r[:width-3]) + "..."
+}
+
+// defStaleBuckets - default -stalebuckets bucket edges in days.
+const defStaleBuckets = "30,90,365"
+
+// parseBuckets - returns the ascending comma separated ints of list, each
+// at least min.
+func parseBuckets(list string, min int) ([]int, error) {
+	var edges []int
+	for _, v := range splitList(list) {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < min {
+			return nil, fmt.Errorf("bucket edge %q not an integer >= %d", v, min)
+		}
+		if len(edges) > 0 && n <= edges[len(edges)-1] {
+			return nil, fmt.Errorf("bucket edges %q not ascending", list)
+		}
+		edges = append(edges, n)
+	}
+	if len(edges) == 0 {
+		return nil, fmt.Errorf("no bucket edges in %q", list)
+	}
+	return edges, nil
+}
+
+// staleness - returns the number of repos of data in each staleness bucket
+// by days since PushedAt relative to now, edges are the bucket edges in days.
+func staleness(data []dataStruct, edges []int, now time.Time) []nameCount {
+	buckets := make([]nameCount, len(edges)+1)
+	for i := range buckets {
+		switch {
+		case i == 0:
+			buckets[i].Name = fmt.Sprintf("<%dd", edges[0])
+		case i == len(edges):
+			buckets[i].Name = fmt.Sprintf(">=%dd", edges[i-1])
+		default:
+			buckets[i].Name = fmt.Sprintf("%d-%dd", edges[i-1], edges[i])
+		}
+	}
+	for _, v := range data {
+		age := days(ageOf(v.PushedAt, now))
+		i := sort.Search(len(edges), func(i int) bool { return age < float64(edges[i]) })
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// defIssueBuckets - default -issuebuckets bucket edges.
+const defIssueBuckets = "0,5,20"
+
+// issueHistogram - returns the number of repos of data in each open issues
+// bucket, edges are the inclusive upper edges e.g. 0,5,20 buckets 0, 1-5,
+// 6-20 and 21+.
+func issueHistogram(data []dataStruct, edges []int) []nameCount {
+	buckets := make([]nameCount, len(edges)+1)
+	low := 0
+	for i := range buckets {
+		switch {
+		case i == len(edges):
+			buckets[i].Name = fmt.Sprintf("%d+", low)
+		case low == edges[i]:
+			buckets[i].Name = strconv.Itoa(low)
+		default:
+			buckets[i].Name = fmt.Sprintf("%d-%d", low, edges[i])
+		}
+		if i < len(edges) {
+			low = edges[i] + 1
+		}
+	}
+	for _, v := range data {
+		i := sort.Search(len(edges), func(i int) bool { return v.OpenIssuesCount <= edges[i] })
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// writeHistogram - writes buckets as labeled bars of #, the biggest count
+// filling width columns with its label and count.
+func writeHistogram(w io.Writer, buckets []nameCount, width int) error {
+	labelw, countw, max := 0, 0, 0
+	for _, b := range buckets {
+		if len(b.Name) > labelw {
+			labelw = len(b.Name)
+		}
+		if n := len(strconv.Itoa(b.Count)); n > countw {
+			countw = n
+		}
+		if b.Count > max {
+			max = b.Count
+		}
+	}
+	barw := width - labelw - countw - 4
+	if barw < 1 {
+		barw = 1
+	}
+	for _, b := range buckets {
+		n := 0
+		if max > 0 {
+			n = b.Count * barw / max
+		}
+		if n == 0 && b.Count > 0 {
+			n = 1
+		}
+		if _, err := fmt.Fprintf(w, "%*s | %-*s %*d\n", labelw, b.Name, barw, strings.Repeat("#", n), countw, b.Count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bigRepo - a -bigrepos repo and its size.
+type bigRepo struct {
+	Name   string `json:"name"`
+	SizeKB int64  `json:"size_kb"`
+	Size   string `json:"size"` // human formatted
+}
+
+// bigRepos - returns the repos of data over size bytes, biggest first.
+func bigRepos(data []dataStruct, size int64, fullname bool) []bigRepo {
+	var out []bigRepo
+	for _, v := range data {
+		if v.Size*1024 > size {
+			out = append(out, bigRepo{v.displayName(fullname), v.Size, humanBytes(v.Size * 1024)})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].SizeKB > out[j].SizeKB })
+	return out
+}
+
+// langSize - a -langsize language and the total size of its repos.
+type langSize struct {
+	Language string `json:"language"` // noLanguage for repos without one
+	Repos    int    `json:"repos"`
+	SizeKB   int64  `json:"size_kb"`
+	Size     string `json:"size"` // human formatted
+}
+
+// noLanguage - the -langsize language of repos GitHub detected none for.
+const noLanguage = "(none)"
+
+// langSizes - returns the total size of the repos of data per primary
+// language, biggest first then by language.
+func langSizes(data []dataStruct) []langSize {
+	idx := make(map[string]int)
+	var out []langSize
+	for _, v := range data {
+		lang := v.Language
+		if lang == "" {
+			lang = noLanguage
+		}
+		i, ok := idx[lang]
+		if !ok {
+			i = len(out)
+			idx[lang] = i
+			out = append(out, langSize{Language: lang})
+		}
+		out[i].Repos++
+		out[i].SizeKB += v.Size
+	}
+	for i := range out {
+		out[i].Size = humanBytes(out[i].SizeKB * 1024)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SizeKB != out[j].SizeKB {
+			return out[i].SizeKB > out[j].SizeKB
+		}
+		return out[i].Language < out[j].Language
+	})
+	return out
+}
+
+// output modes for reportOpts.output
+const (
+	outputText        = "text"
+	outputJSON        = "json"
+	outputNames       = "names"
+	outputCSV         = "csv"
+	outputProm        = "prometheus"
+	outputNDJSON      = "ndjson"
+	outputYAML        = "yaml"
+	outputCounts      = "counts"
+	outputXLSX        = "xlsx"
+	outputTSCSV       = "tscsv"
+	outputPrettyTable = "prettytable"
+	outputMetrics     = "metrics"
+	outputHistogram   = "histogram"
+)
+
+// outputExts - the {ext} of each output mode in an -outfile template.
+var outputExts = map[string]string{
+	outputText:        "txt",
+	outputJSON:        "json",
+	outputNames:       "names",
+	outputCSV:         "csv",
+	outputProm:        "prom",
+	outputNDJSON:      "ndjson",
+	outputYAML:        "yaml",
+	outputCounts:      "counts",
+	outputXLSX:        "xlsx",
+	outputTSCSV:       "ts.csv",
+	outputPrettyTable: "table.txt",
+	outputMetrics:     "metrics.txt",
+	outputHistogram:   "hist.txt",
+}
+
+// outputModeNames - returns the sorted output modes.
+func outputModeNames() []string {
+	var names []string
+	for k := range outputExts {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseOutputs - returns the comma separated output modes of outputs, erroring
+// on unknown or repeated modes, "" means outputText.
+func parseOutputs(outputs string) ([]string, error) {
+	list := splitList(outputs)
+	if len(list) == 0 {
+		return []string{outputText}, nil
+	}
+	for i, o := range list {
+		if _, ok := outputExts[o]; !ok {
+			return nil, fmt.Errorf("unknown output mode %q valid:%s", o, strings.Join(outputModeNames(), ","))
+		}
+		if hasString(list[:i], o) {
+			return nil, fmt.Errorf("output mode %q repeated", o)
+		}
+	}
+	return list, nil
+}
+
+// appendSeparator - returns the line -append writes before the output of a
+// run at t, only for the text like modes, and yaml and prometheus whose
+// comments start with #, as it'd be a bad record in the others.
+func appendSeparator(output string, t time.Time) string {
+	switch output {
+	case outputText, outputPrettyTable, outputCounts, outputMetrics, outputHistogram, outputYAML, outputProm:
+		return "# ghrepo run " + t.UTC().Format(time.RFC3339) + "\n"
+	}
+	return ""
+}
+
+// appendsRows - reports whether -append of output mode output to path adds
+// rows to a csv that already has its header row.
+func appendsRows(output, path string) bool {
+	if output != outputCSV && output != outputTSCSV {
+		return false
+	}
+	fi, err := os.Stat(path)
+	return err == nil && fi.Size() > 0
+}
+
+// outfileName - returns the -outfile template tmpl with {ext} replaced by
+// the extension of output mode output.
+func outfileName(tmpl, output string) string {
+	return strings.Replace(tmpl, "{ext}", outputExts[output], -1)
+}
+
+// reportOutput - a further output mode of a run and where it's written.
+type reportOutput struct {
+	output     string
+	w          io.Writer
+	appendRows bool // see reportOpts.appendRows
+}
+
+// sorterFor - returns g as the interface2 sorting by sortby with its title
+// and sort direction set.
+func sorterFor(sortby sortType, g ghStruct) interface2 {
+	g.sortasc = sortby&sascending > 0
+	asctxt := "ascending"
+	if !g.sortasc {
+		asctxt = "descending"
+	}
+	switch {
+	case sortby&sbyExpr > 0:
+		g.title = "bySortExpr " + asctxt
+		return newByExpr(g)
+	case sortby&sbyIssueRatio > 0:
+		g.title = "byIssueRatio " + asctxt
+		return byIssueRatio(g)
+	case sortby&sbySubscribers > 0:
+		g.title = "bySubscribers " + asctxt
+		return bySubscribers(g)
+	case sortby&sbyPushedAt > 0:
+		g.title = "byPushedAt " + asctxt
+		return byPushedAt(g)
+	default:
+		fallthrough
+	case sortby&sbyUpdatedAt > 0:
+		g.title = "byUpdatedAt " + asctxt
+		return byUpdatedAt(g)
+	}
+}
+
+// reportOpts - options controlling how the report is displayed.
+type reportOpts struct {
+	loc            *time.Location     // time zone for displayed timestamps, nil leaves them as returned (UTC)
+	timePrecision  string             // truncate text and csv timestamps to this timePrecisions unit, "" leaves them
+	ascii          bool               // draw prettytable borders with ascii rather than box drawing characters
+	showOwner      bool               // display repos as owner/name even for a single source
+	mergeStrategy  string             // mergeStrategies name of the copy -dedup keeps
+	skipDisabled   bool               // drop repos disabled by GitHub before summarizing
+	skipMirrors    bool               // drop mirror repos before summarizing
+	verbose        int                // verbose level, >0 adds per repo flags to the listing
+	output         string             // output mode see output modes, "" means outputText
+	top            int                // list only the first top repos after sorting, 0 lists all
+	span           bool               // show per repo activity span and the average span
+	subscribers    bool               // get and report subscribers_count (true watchers)
+	noHeader       bool               // suppress the report name, url and endOfReport lines
+	appendRows     bool               // csv rows without the header row, -append to a csv that has it
+	groupBy        string             // sub-total by this, see groupBy values, "" means no grouping
+	fetch          fetchOpts          // see fetchOpts
+	ratio          bool               // show per repo open issues to watchers ratio
+	includeEmpty   bool               // include owners without repos in the groupBy owner sub-totals
+	fields         []string           // extra per repo columns, see extraFields
+	fieldWidth     int                // truncate extra field values in text output to this width, 0 no limit
+	sample         int                // report on a random sample of this many repos, 0 reports on all
+	seed           int64              // seed for drawing the sample
+	orgInfo        bool               // get and show org metadata in the header for org urls
+	topicsAll      []string           // keep only repos having all of these topics
+	topicsAny      []string           // keep only repos having at least one of these topics
+	languages      []string           // keep only repos whose primary language is one of these
+	promRepos      bool               // add per repo gauges in prometheus output
+	policy         policyStruct       // limits checked against every reported repo, nil checks none
+	staleBuckets   []int              // staleness bucket edges in days, nil reports no staleness
+	issueBuckets   []int              // -output histogram inclusive bucket upper edges
+	histWidth      int                // -output histogram line width, 0 the terminal width
+	maxNameWidth   int                // truncate repo names in the text listing to this width, 0 no limit
+	summaryFirst   bool               // ndjson summary line goes first on writer instead of to stderr
+	issuesPRs      bool               // label open issue counts as issues+prs, which GitHub counts them as
+	title          string             // report name in the header, trailer and json envelope, "" means defReportName
+	include        []string           // keep only repos with these names, applied before exclude
+	includeWarn    bool               // warn instead of erroring on include names not found
+	exclude        []string           // drop repos with these names (full_name for multiple urls)
+	flatten        bool               // append the run totals to every csv row
+	redact         bool               // replace private repo names with placeholders
+	summaryJSON    string             // also write the totals as json to this file, "" writes none
+	dedup          bool               // drop repeats of the same repo id before summarizing
+	showDesc       bool               // show descriptions under the repo names in text output
+	wrap           int                // wrap descriptions to this width, 0 the terminal width, <0 no wrapping
+	releases       bool               // get release counts with a request per repo
+	protection     bool               // get default branch protection with a request per repo
+	minAge         time.Duration      // keep only repos created at least this long ago, 0 keeps all
+	verifyStars    int                // re-get the star counts of this many most starred repos from the repo endpoint
+	botCheck       int                // check the commits of this many most recently pushed repos for bot only activity
+	botDays        int                // days of commits -botcheck looks at
+	bots           []string           // logins besides [bot] and app accounts -botcheck counts as bots
+	sortExpr       *template.Template // per repo sort key of sbyExpr sorts, see parseSortExpr
+	annotate       bool               // annotate each listed repo's open issues relative to the average
+	moreOutputs    []reportOutput     // further output modes rendered from the same data after output
+	sinceLastRun   string             // state file of the repos pushed_at compared to and replaced each run
+	requireLicense bool               // public repos without a license are policy violations
+	features       bool               // show the issues/wiki/projects enabled counts in text output
+	silent         bool               // report output only goes to -outfile, see stdout
+	highlight      string             // summaryMetrics name of the summary's most repo line, "" means defHighlight
+	tiebreak       string             // tieBreaks name ordering the repos tied on a most repo line
+	deadDays       int                // count repos without watchers and open issues not pushed in this many days, 0 counts none
+	noTopics       bool               // count and list the repos without any topic
+	sep            string             // separator between the fields of text listing lines, "" is defSep
+	empty          bool               // count the empty repos, see dataStruct.empty
+	skipEmpty      bool               // drop empty repos before summarizing
+	bigSize        int64              // list repos over this many bytes, 0 lists none
+	langSize       bool               // total the repo sizes per language
+	colors         []colorRule        // color text output repo lines by push age, nil colors none
+	relative       bool               // add pushed and updated ages in seconds to each repo of structured output
+}
+
+// groupBy values for reportOpts.groupBy
+const (
+	groupByOwner = "owner"
+)
+
+// groupStruct - sub-totals of the repos of a group.
+type groupStruct struct {
+	Name       string `json:"name"`
+	Repos      int    `json:"repos"`
+	OpenIssues int    `json:"open_issues"`
+}
+
+// urlOwner - returns the user or org name of a github api repos url such as
+// https://api.github.com/orgs/gorilla/repos or "" when there isn't one.
+func urlOwner(urlname string) string {
+	u, err := url.Parse(urlname)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "users" || parts[i] == "orgs" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// groupData - returns the sub-totals of data grouped by owner sorted by name,
+// each of emptyOwners without repos is included with zero counts.
+func groupData(data []dataStruct, emptyOwners []string) []groupStruct {
+	idx := make(map[string]int)
+	var groups []groupStruct
+	for _, v := range data {
+		i, ok := idx[v.owner()]
+		if !ok {
+			i = len(groups)
+			idx[v.owner()] = i
+			groups = append(groups, groupStruct{Name: v.owner()})
+		}
+		groups[i].Repos++
+		groups[i].OpenIssues += v.OpenIssuesCount
+	}
+	for _, owner := range emptyOwners {
+		found := false
+		for _, g := range groups {
+			if strings.EqualFold(g.Name, owner) {
+				found = true
+				break
+			}
+		}
+		if !found && owner != "" {
+			groups = append(groups, groupStruct{Name: owner})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// summaryStruct - aggregate info of a report.
+type summaryStruct struct {
+	Repos             int           `json:"repos"`
+	TotOpenIssues     int           `json:"tot_open_issues"`
+	TotStars          int           `json:"tot_stars"`
+	TotForks          int           `json:"tot_forks"`
+	MostWatchersRepo  string        `json:"most_watchers_repo"`
+	MaxWatchers       int           `json:"max_watchers"`
+	TotSubscribers    int           `json:"tot_subscribers"`
+	MostSubscribers   string        `json:"most_subscribers_repo"`
+	MaxSubscribers    int           `json:"max_subscribers"`
+	Highlight         string        `json:"highlight,omitempty"` // -highlight metric when not watchers
+	MostHighlightRepo string        `json:"most_highlight_repo,omitempty"`
+	MaxHighlight      int           `json:"max_highlight,omitempty"`
+	DisabledRepos     int           `json:"disabled_repos"`
+	MirrorRepos       int           `json:"mirror_repos"`
+	OrgOwnedRepos     int           `json:"org_owned_repos"`
+	UserOwnedRepos    int           `json:"user_owned_repos"`
+	AvgSpanDays       float64       `json:"avg_span_days"`
+	Staleness         []nameCount   `json:"staleness,omitempty"`
+	SampleOf          int           `json:"sample_of,omitempty"`     // repos the sample was drawn from
+	Seed              int64         `json:"seed,omitempty"`          // seed the sample was drawn with
+	SkippedPages      []string      `json:"skipped_pages,omitempty"` // -besteffort pages that failed to parse, the report is partial
+	HasIssues         int           `json:"has_issues"`
+	HasWiki           int           `json:"has_wiki"`
+	HasProjects       int           `json:"has_projects"`
+	IssuesAnomalies   []string      `json:"issues_anomalies,omitempty"`  // repos with issues disabled but open issues counted
+	LikelyDead        *int          `json:"likely_dead,omitempty"`       // -likelydead count, repos without watchers, open issues or recent pushes
+	LikelyDeadRepos   []string      `json:"likely_dead_repos,omitempty"` // names of the LikelyDead repos
+	NoTopics          *int          `json:"no_topics,omitempty"`         // -notopics count of repos without any topic
+	NoTopicsRepos     []string      `json:"no_topics_repos,omitempty"`
+	Empty             *int          `json:"empty,omitempty"`                  // -empty count of repos without commits
+	EmptyRepos        []string      `json:"empty_repos,omitempty"`            // names of the Empty repos
+	BigRepos          []bigRepo     `json:"big_repos,omitempty"`              // -bigrepos repos over -bigsize, biggest first
+	LangSizes         []langSize    `json:"lang_sizes,omitempty"`             // -langsize total size per language, biggest first
+	Protected         *int          `json:"protected,omitempty"`              // -protection count of repos with a protected default branch
+	UnprotectedRepos  []string      `json:"unprotected_repos,omitempty"`      // checked repos whose default branch isn't protected
+	BotChecked        int           `json:"bot_checked,omitempty"`            // -botcheck repos checked
+	BotOnlyRepos      []string      `json:"bot_only_repos,omitempty"`         // checked repos whose recent commits are all by bots
+	LastRun           *time.Time    `json:"last_run,omitempty"`               // time of the -sincelastrun run compared to
+	ChangedSinceLast  []changedRepo `json:"changed_since_last_run,omitempty"` // repos pushed since LastRun
+}
+
+// nameCount - a named count, json output uses slices of these rather than
+// maps so the emitted order is stable.
+type nameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// ndjsonSummary - the summary line of ndjson output mode.
+type ndjsonSummary struct {
+	Type     string `json:"type"` // always "summary"
+	Report   string `json:"report"`
+	URL      string `json:"url"`
+	SortedBy string `json:"sorted_by"`
+	summaryStruct
+}
+
+// summarySidecar - the -summaryjson file contents, just the totals and most
+// watched/subscribed repos.
+type summarySidecar struct {
+	Report           string    `json:"report"`
+	URL              string    `json:"url"`
+	Time             time.Time `json:"time"`
+	Repos            int       `json:"repos"`
+	TotOpenIssues    int       `json:"tot_open_issues"`
+	MostWatchersRepo string    `json:"most_watchers_repo"`
+	MaxWatchers      int       `json:"max_watchers"`
+	TotSubscribers   int       `json:"tot_subscribers,omitempty"`
+	MostSubscribers  string    `json:"most_subscribers_repo,omitempty"`
+	MaxSubscribers   int       `json:"max_subscribers,omitempty"`
+}
+
+// writeSummaryJSON - writes the summarySidecar of sum to file atomically.
+func writeSummaryJSON(file, reportName, urlname string, sum summaryStruct, subscribers bool) error {
+	side := summarySidecar{
+		Report:           reportName,
+		URL:              urlname,
+		Time:             time.Now().UTC(),
+		Repos:            sum.Repos,
+		TotOpenIssues:    sum.TotOpenIssues,
+		MostWatchersRepo: sum.MostWatchersRepo,
+		MaxWatchers:      sum.MaxWatchers,
+	}
+	if subscribers {
+		side.TotSubscribers = sum.TotSubscribers
+		side.MostSubscribers = sum.MostSubscribers
+		side.MaxSubscribers = sum.MaxSubscribers
+	}
+	af, err := createAtomic(file)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(af)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err = enc.Encode(side); err != nil {
+		af.abort()
+		return err
+	}
+	return af.commit()
+}
+
+// ndjsonRepo - a repo line of ndjson output mode.
+type ndjsonRepo struct {
+	Type string `json:"type"` // always "repo"
+	dataStruct
+}
+
+// jsonReport - layout of the report in json output mode. It is built only
+// from structs and slices (never maps) so that the same dataset always
+// marshals to the same bytes and json outputs can be diffed.
+type jsonReport struct {
+	Report   string        `json:"report"`
+	URL      string        `json:"url"`
+	SortedBy string        `json:"sorted_by"`
+	Orgs     []orgInfo     `json:"orgs,omitempty"`
+	Summary  summaryStruct `json:"summary"`
+	Groups   []groupStruct `json:"groups,omitempty"`
+	Repos    []dataStruct  `json:"repos"`
+}
+
+// setAges - sets the -relative pushed and updated ages of data as of now.
+func setAges(data []dataStruct, now time.Time) {
+	for i := range data {
+		pushed := int64(ageOf(data[i].PushedAt, now) / time.Second)
+		updated := int64(ageOf(data[i].UpdatedAt, now) / time.Second)
+		data[i].PushedAgeSeconds, data[i].UpdatedAgeSeconds = &pushed, &updated
+	}
+}
+
+// defReportName - report name used unless -title is given.
+const defReportName = "GitHubReposReportSummary"
+
+// gitHubReposReportSummary - generates a summary for a given github url that
+// includes: totOpenIssues, mostWatchersRepo and a sorted list of repos by sortType
+// - ctx     - context for the api requests
+// - urlnames - names of github urls for getting repos info
+// - writer  - io.Writer to generate output too.
+// - sorttype - see sortType values
+// - opts    - see reportOpts
+func gitHubReposReportSummary(ctx context.Context, urlnames []string, writer io.Writer, sortby sortType, opts reportOpts) error {
+	reportName := defReportName
+	if opts.title != "" {
+		reportName = opts.title
+	}
+	urlname := strings.Join(urlnames, ",")
+	fullname := len(urlnames) > 1 || opts.showOwner
+	if sortby&sbySubscribers > 0 {
+		opts.subscribers = true
+	}
+
+	data, skippedPages, err := getAllData(ctx, urlnames, opts.fetch)
+	if err != nil {
+		return err
+	}
+	var orgs []orgInfo
+	if opts.orgInfo && !opts.fetch.demo {
+		if orgs, err = getOrgInfo(ctx, urlnames); err != nil {
+			return err
+		}
+	}
+
+	if opts.dedup {
+		var dups int
+		data, dups = dedupByID(data, mergeStrategies[opts.mergeStrategy])
+		if opts.verbose > 0 {
+			log.Printf("%s: dropped %d duplicate repos\n", urlname, dups)
+		}
+	}
+	for _, w := range repoCountWarnings(orgs, data) {
+		log.Printf("%s: warning %s\n", urlname, w)
+	}
+
+	var runSt runState
+	if opts.sinceLastRun != "" {
+		// of everything fetched, so repos outside the filters or -sample
+		// aren't new next run.
+		runSt = newRunState(data)
+	}
+	sum := summaryStruct{SkippedPages: skippedPages}
+	for _, v := range data {
+		if v.Disabled {
+			sum.DisabledRepos++
+		}
+		if v.MirrorURL != "" {
+			sum.MirrorRepos++
+		}
+	}
+	if len(opts.include) > 0 {
+		included := make(map[string]bool)
+		for _, v := range opts.include {
+			included[v] = false
+		}
+		data = filterData(data, func(d dataStruct) bool {
+			name := d.displayName(fullname)
+			if _, ok := included[name]; ok {
+				included[name] = true
+				return true
+			}
+			return false
+		})
+		var missing []string
+		for _, v := range opts.include {
+			if !included[v] {
+				missing = append(missing, v)
+			}
+		}
+		if len(missing) > 0 {
+			if !opts.includeWarn {
+				return fmt.Errorf("included repos not found: %s", strings.Join(missing, ","))
+			}
+			log.Printf("%s: warning included repos not found: %s\n", urlname, strings.Join(missing, ","))
+		}
+	}
+	if len(opts.exclude) > 0 {
+		excluded := make(map[string]bool)
+		for _, v := range opts.exclude {
+			excluded[v] = true
+		}
+		n := len(data)
+		data = filterData(data, func(d dataStruct) bool { return !excluded[d.displayName(fullname)] })
+		if opts.verbose > 0 {
+			log.Printf("%s: excluded %d repos\n", urlname, n-len(data))
+		}
+	}
+	if opts.skipDisabled {
+		data = filterData(data, func(d dataStruct) bool { return !d.Disabled })
+	}
+	if opts.skipMirrors {
+		data = filterData(data, func(d dataStruct) bool { return d.MirrorURL == "" })
+	}
+	if opts.skipEmpty {
+		data = filterData(data, func(d dataStruct) bool { return !d.empty() })
+	}
+	if len(opts.topicsAll) > 0 {
+		data = filterData(data, func(d dataStruct) bool { return d.hasAllTopics(opts.topicsAll) })
+	}
+	if len(opts.topicsAny) > 0 {
+		data = filterData(data, func(d dataStruct) bool { return d.hasAnyTopic(opts.topicsAny) })
+	}
+	if len(opts.languages) > 0 {
+		data = filterData(data, func(d dataStruct) bool { return d.hasLanguage(opts.languages) })
+	}
+	if opts.minAge > 0 {
+		now := time.Now()
+		data = filterData(data, func(d dataStruct) bool { return now.Sub(d.CreatedAt) >= opts.minAge })
+	}
+	if opts.sample > 0 && opts.sample < len(data) {
+		sum.SampleOf = len(data)
+		sum.Seed = opts.seed
+		r := rand.New(rand.NewSource(opts.seed))
+		r.Shuffle(len(data), func(i, j int) { data[i], data[j] = data[j], data[i] })
+		data = data[:opts.sample]
+	}
+	if opts.subscribers && !opts.fetch.demo {
+		if err = getSubscribers(ctx, data); err != nil {
+			return err
+		}
+	}
+	if opts.verifyStars > 0 && !opts.fetch.demo {
+		fixed, err := verifyStars(ctx, data, opts.verifyStars, opts.verbose)
+		if err != nil {
+			return err
+		}
+		if opts.verbose > 0 {
+			log.Printf("%s: verified stars of top %d repos, %d corrected\n", urlname, opts.verifyStars, fixed)
+		}
+	}
+	var botChecked int
+	if opts.botCheck > 0 && !opts.fetch.demo {
+		if botChecked, err = checkBotPushes(ctx, data, opts.botCheck, time.Duration(opts.botDays)*24*time.Hour, opts.bots, opts.fetch); err != nil {
+			return err
+		}
+	}
+	if opts.releases && !opts.fetch.demo {
+		if err = getReleases(ctx, data, opts.fetch); err != nil {
+			return err
+		}
+	}
+	if opts.protection && !opts.fetch.demo {
+		if err = getProtection(ctx, data, opts.fetch); err != nil {
+			return err
+		}
+	}
+	if opts.redact {
+		// after the per repo requests, which need the urls redaction blanks.
+		redactPrivate(data)
+	}
+
+	sum.Repos = len(data)
+	var totSpan time.Duration
+	for _, v := range data {
+		totSpan += v.span()
+		if v.HasIssues {
+			sum.HasIssues++
+		} else if v.OpenIssuesCount > 0 {
+			// open_issues_count includes pull requests, which still work with issues disabled.
+			sum.IssuesAnomalies = append(sum.IssuesAnomalies, v.displayName(fullname))
+		}
+		if v.HasWiki {
+			sum.HasWiki++
+		}
+		if v.HasProjects {
+			sum.HasProjects++
+		}
+		switch v.Owner.Type {
+		case ownerOrg:
+			sum.OrgOwnedRepos++
+		case ownerUser:
+			sum.UserOwnedRepos++
+		}
+		if v.WatchersCount < 0 {
+			return fmt.Errorf("WatchersCount is negative! %v", v.String())
+		}
+	}
+	acc := accumulate(data, summaryMetrics, opts.tiebreak)
+	sum.TotOpenIssues = acc["open_issues"].total
+	sum.TotStars, sum.TotForks = acc["stars"].total, acc["forks"].total
+	sum.MostWatchersRepo, sum.MaxWatchers = acc[defHighlight].mostTxt(fullname), acc[defHighlight].max
+	sum.TotSubscribers = acc["subscribers"].total
+	sum.MostSubscribers, sum.MaxSubscribers = acc["subscribers"].mostTxt(fullname), acc["subscribers"].max
+	if opts.highlight != "" && opts.highlight != defHighlight {
+		sum.Highlight = opts.highlight
+		sum.MostHighlightRepo, sum.MaxHighlight = acc[opts.highlight].mostTxt(fullname), acc[opts.highlight].max
+	}
+	if opts.staleBuckets != nil {
+		sum.Staleness = staleness(data, opts.staleBuckets, time.Now())
+	}
+	if opts.deadDays > 0 {
+		stale := time.Now().Add(-time.Duration(opts.deadDays) * 24 * time.Hour)
+		dead := 0
+		for _, v := range data {
+			if v.WatchersCount == 0 && v.OpenIssuesCount == 0 && v.PushedAt.Before(stale) {
+				dead++
+				sum.LikelyDeadRepos = append(sum.LikelyDeadRepos, v.displayName(fullname))
+			}
+		}
+		sum.LikelyDead = &dead
+	}
+	if opts.noTopics {
+		none := 0
+		for _, v := range data {
+			if len(v.Topics) == 0 {
+				none++
+				sum.NoTopicsRepos = append(sum.NoTopicsRepos, v.displayName(fullname))
+			}
+		}
+		sum.NoTopics = &none
+	}
+	if opts.empty {
+		empty := 0
+		for _, v := range data {
+			if v.empty() {
+				empty++
+				sum.EmptyRepos = append(sum.EmptyRepos, v.displayName(fullname))
+			}
+		}
+		sum.Empty = &empty
+	}
+	if opts.protection && !opts.fetch.demo {
+		protected := 0
+		for _, v := range data {
+			if v.Protected == nil {
+				continue
+			}
+			if *v.Protected {
+				protected++
+			} else {
+				sum.UnprotectedRepos = append(sum.UnprotectedRepos, v.displayName(fullname))
+			}
+		}
+		sum.Protected = &protected
+	}
+	sum.BotChecked = botChecked
+	for _, v := range data {
+		if v.BotOnly {
+			sum.BotOnlyRepos = append(sum.BotOnlyRepos, v.displayName(fullname))
+		}
+	}
+	if opts.bigSize > 0 {
+		sum.BigRepos = bigRepos(data, opts.bigSize, fullname)
+	}
+	if opts.langSize {
+		sum.LangSizes = langSizes(data)
+	}
+	if opts.sinceLastRun != "" {
+		st, err := loadRunState(opts.sinceLastRun)
+		if err != nil {
+			return fmt.Errorf("-sincelastrun: %w", err)
+		}
+		if st != nil {
+			sum.LastRun = &st.Time
+			sum.ChangedSinceLast = changedSince(st, data, fullname)
+		}
+	}
+
+	var groups []groupStruct
+	wantProm := opts.output == outputProm
+	for _, o := range opts.moreOutputs {
+		wantProm = wantProm || o.output == outputProm
+	}
+	if opts.groupBy == groupByOwner || wantProm {
+		var emptyOwners []string
+		if opts.includeEmpty {
+			for _, urlname := range urlnames {
+				emptyOwners = append(emptyOwners, urlOwner(urlname))
+			}
+		}
+		groups = groupData(data, emptyOwners)
+	}
+	if sum.Repos > 0 {
+		sum.AvgSpanDays = days(totSpan) / float64(sum.Repos)
+	}
+
+	bdata := sorterFor(sortby, ghStruct{data: data, loc: opts.loc, prec: opts.timePrecision, fullname: fullname, expr: opts.sortExpr})
+	sort.Sort(bdata)
+	listed := bdata.Len()
+	if opts.top > 0 && opts.top < listed {
+		listed = opts.top
+	}
+
+	var violations []policyViolation
+	if opts.policy != nil {
+		violations = checkPolicy(opts.policy, data, fullname)
+	}
+	if opts.requireLicense {
+		violations = append(violations, checkLicenses(data, fullname)...)
+	}
+	if opts.relative {
+		setAges(data[:listed], time.Now())
+	}
+	if err = writeReport(writer, reportName, urlname, bdata, data[:listed], sum, groups, orgs, opts); err != nil {
+		return err
+	}
+	for _, o := range opts.moreOutputs {
+		mopts := opts
+		mopts.output, mopts.appendRows = o.output, o.appendRows
+		if err = writeReport(o.w, reportName, urlname, bdata, data[:listed], sum, groups, orgs, mopts); err != nil {
+			return fmt.Errorf("-output %s: %w", o.output, err)
+		}
+	}
+	if opts.sinceLastRun != "" {
+		if err = saveRunState(opts.sinceLastRun, runSt); err != nil {
+			return fmt.Errorf("-sincelastrun: %w", err)
+		}
+	}
+	if opts.summaryJSON != "" {
+		if err = writeSummaryJSON(opts.summaryJSON, reportName, urlname, sum, opts.subscribers); err != nil {
+			return fmt.Errorf("-summaryjson: %w", err)
+		}
+	}
+	if len(violations) > 0 {
+		return &policyError{violations}
+	}
+	return nil
+}
+
+// reportTable - returns the csv and xlsx table of the first listed repos of
+// bdata, the header row first.
+func reportTable(bdata interface2, listed int, sum summaryStruct, opts reportOpts) [][]string {
+	header := []string{"i", bdata.FieldName(), "name"}
+	for _, f := range opts.fields {
+		header = append(header, fieldLabel(f, opts))
+	}
+	if opts.flatten {
+		header = append(header, "tot_"+fieldLabel("open_issues", opts), "repos")
+	}
+	rows := [][]string{header}
+	for i := 0; i < listed; i++ {
+		row := []string{strconv.Itoa(i), bdata.Field(i), bdata.Name(i)}
+		for _, f := range opts.fields {
+			row = append(row, extraFields[f](bdata.Repo(i), opts))
+		}
+		if opts.flatten {
+			row = append(row, strconv.Itoa(sum.TotOpenIssues), strconv.Itoa(sum.Repos))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// prettyTable - reportTable with the name column truncated to
+// opts.maxNameWidth and the -span, -ratio and -annotate columns the text
+// listing shows after the name, issuesLabel heading the -annotate one.
+func prettyTable(bdata interface2, listed int, sum summaryStruct, avgIssues float64, issuesLabel string, opts reportOpts) [][]string {
+	rows := reportTable(bdata, listed, sum, opts)
+	for i, row := range rows {
+		var extra []string
+		if i == 0 {
+			if opts.span {
+				extra = append(extra, "span")
+			}
+			if opts.ratio {
+				extra = append(extra, "ratio")
+			}
+			if opts.annotate {
+				extra = append(extra, issuesLabel)
+			}
+		} else {
+			d := bdata.Repo(i - 1)
+			row[2] = truncate(row[2], opts.maxNameWidth)
+			if opts.span {
+				extra = append(extra, fmt.Sprintf("%.0fd", days(d.span())))
+			}
+			if opts.ratio {
+				extra = append(extra, fmt.Sprintf("%.2f", d.issueRatio()))
+			}
+			if opts.annotate {
+				extra = append(extra, vsAverageTxt(d.OpenIssuesCount, avgIssues))
+			}
+		}
+		rows[i] = append(append(append([]string{}, row[:3]...), extra...), row[3:]...)
+	}
+	return rows
+}
+
+// writeReport - writes the report in opts.output mode, bdata is the sorted
+// data whose first len(repos) elements, repos, are listed.
+func writeReport(writer io.Writer, reportName, urlname string, bdata interface2, repos []dataStruct,
+	sum summaryStruct, groups []groupStruct, orgs []orgInfo, opts reportOpts) error {
+	listed := len(repos)
+	issuesLabel := func(label string) string {
+		if opts.issuesPRs {
+			return label + prsSuffix
+		}
+		return label
+	}
+	switch opts.output {
+	case outputCounts:
+		// fixed order for scraping: repos open_issues total_stars total_forks
+		_, err := fmt.Fprintf(writer, "%d %d %d %d\n", sum.Repos, sum.TotOpenIssues, sum.TotStars, sum.TotForks)
+		return err
+	case outputNames:
+		for i := 0; i < listed; i++ {
+			fmt.Fprintln(writer, bdata.Name(i))
+		}
+		return nil
+	case outputNDJSON:
+		line := ndjsonSummary{"summary", reportName, urlname, bdata.Title(), sum}
+		if opts.summaryFirst {
+			if err := json.NewEncoder(writer).Encode(line); err != nil {
+				return err
+			}
+		} else if !opts.silent {
+			if err := json.NewEncoder(os.Stderr).Encode(line); err != nil {
+				return err
+			}
+		}
+		enc := json.NewEncoder(writer)
+		for _, v := range repos {
+			if err := enc.Encode(ndjsonRepo{"repo", v}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputProm:
+		return writePrometheus(writer, sum, groups, repos, opts.promRepos)
+	case outputMetrics:
+		mopts := opts
+		if len(mopts.fields) == 0 {
+			mopts.fields = metricsFields(opts)
+		}
+		tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		for _, row := range reportTable(bdata, listed, sum, mopts) {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	case outputHistogram:
+		if !opts.noHeader {
+			fmt.Fprintf(writer, "%s histogram of %d repos by open issues:\n", reportName, listed)
+		}
+		return writeHistogram(writer, issueHistogram(repos, opts.issueBuckets), wrapWidth(opts.histWidth))
+	case outputCSV, outputTSCSV:
+		w := csv.NewWriter(writer)
+		runAt := time.Now().UTC().Format(time.RFC3339)
+		for i, row := range reportTable(bdata, listed, sum, opts) {
+			if i == 0 && opts.appendRows {
+				continue
+			}
+			if opts.output == outputTSCSV {
+				// run_at first so appended runs build a time series.
+				ts := runAt
+				if i == 0 {
+					ts = "run_at"
+				}
+				row = append([]string{ts}, row...)
+			}
+			_ = w.Write(row)
+		}
+		w.Flush()
+		return w.Error()
+	case outputXLSX:
+		srows, err := summaryRows(sum)
+		if err != nil {
+			return err
+		}
+		return writeXLSX(writer, []xlsxSheet{{"repos", reportTable(bdata, listed, sum, opts)}, {"summary", srows}})
+	case outputJSON, outputYAML:
+		report := jsonReport{
+			Report:   reportName,
+			URL:      urlname,
+			SortedBy: bdata.Title(),
+			Orgs:     orgs,
+			Summary:  sum,
+			Groups:   groups,
+			Repos:    repos,
+		}
+		if opts.output == outputYAML {
+			return writeYAML(writer, report)
+		}
+		enc := json.NewEncoder(writer)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(report)
+	case outputText, outputPrettyTable:
+	default:
+		return fmt.Errorf("unknown output mode %q valid:%s", opts.output, strings.Join(outputModeNames(), ","))
+	}
+
+	if !opts.noHeader {
+		fmt.Fprintf(writer, "%s:\nPublic accessible info for %s\n", reportName, urlname)
+		for _, org := range orgs {
+			fmt.Fprintf(writer, "org:%s name:%q publicRepos:%d blog:%s location:%q\n",
+				org.Login, org.Name, org.PublicRepos, org.Blog, org.Location)
+		}
+	}
+	for _, g := range groups {
+		fmt.Fprintf(writer, "%s:%s repos:%d %s:%d\n", opts.groupBy, g.Name, g.Repos, issuesLabel("openIssues"), g.OpenIssues)
+	}
+	if len(sum.SkippedPages) > 0 {
+		fmt.Fprintf(writer, "partial report, skipped unparsable pages: %s\n", strings.Join(sum.SkippedPages, ", "))
+	}
+	if sum.SampleOf > 0 {
+		fmt.Fprintf(writer, "sample:%d of %d repos seed:%d\n", sum.Repos, sum.SampleOf, sum.Seed)
+	}
+	if sum.Highlight != "" {
+		label := summaryMetrics[sum.Highlight].label
+		if sum.Highlight == "open_issues" {
+			label = issuesLabel(label)
+		}
+		fmt.Fprintf(writer, "%s:%d most%sRepo:%s [max%s:%d]\n", issuesLabel("totOpenIssues"),
+			sum.TotOpenIssues, label, sum.MostHighlightRepo, label, sum.MaxHighlight)
+	} else {
+		fmt.Fprintf(writer, "%s:%d mostWatchersRepo:%s [maxWatchers:%d]\n", issuesLabel("totOpenIssues"),
+			sum.TotOpenIssues, sum.MostWatchersRepo, sum.MaxWatchers)
+	}
+	if opts.subscribers {
+		fmt.Fprintf(writer, "totSubscribers:%d mostSubscribersRepo:%s [maxSubscribers:%d] (watchers above are stars)\n",
+			sum.TotSubscribers, sum.MostSubscribers, sum.MaxSubscribers)
+	}
+	fmt.Fprintf(writer, "disabledRepos:%d%s mirrorRepos:%d%s\n",
+		sum.DisabledRepos, skippedTxt(opts.skipDisabled), sum.MirrorRepos, skippedTxt(opts.skipMirrors))
+	if sum.OrgOwnedRepos > 0 && sum.UserOwnedRepos > 0 {
+		// only worth a line when the owners are mixed e.g. several -ghurl.
+		fmt.Fprintf(writer, "orgOwnedRepos:%d userOwnedRepos:%d\n", sum.OrgOwnedRepos, sum.UserOwnedRepos)
+	}
+	if opts.span {
+		fmt.Fprintf(writer, "avgSpan:%.1fdays\n", sum.AvgSpanDays)
+	}
+	if len(sum.Staleness) > 0 {
+		fmt.Fprintf(writer, "staleness(since push):")
+		for _, b := range sum.Staleness {
+			fmt.Fprintf(writer, " %s:%d", b.Name, b.Count)
+		}
+		fmt.Fprintln(writer)
+	}
+	if opts.features {
+		fmt.Fprintf(writer, "hasIssues:%d hasWiki:%d hasProjects:%d\n", sum.HasIssues, sum.HasWiki, sum.HasProjects)
+		if len(sum.IssuesAnomalies) > 0 {
+			fmt.Fprintf(writer, "issues disabled but %s counted: %s\n", issuesLabel("openIssues"), strings.Join(sum.IssuesAnomalies, ","))
+		}
+	}
+	if sum.LikelyDead != nil {
+		fmt.Fprintf(writer, "likelyDead:%d (0 watchers, 0 open issues, not pushed in %dd)\n", *sum.LikelyDead, opts.deadDays)
+		if opts.verbose > 0 {
+			for _, name := range sum.LikelyDeadRepos {
+				fmt.Fprintf(writer, "  likelyDead: %s\n", name)
+			}
+		}
+	}
+	if sum.NoTopics != nil {
+		fmt.Fprintf(writer, "noTopics:%d\n", *sum.NoTopics)
+		if len(sum.NoTopicsRepos) > 0 {
+			fmt.Fprintf(writer, "  noTopics: %s\n", strings.Join(sum.NoTopicsRepos, ","))
+		}
+	}
+	if sum.Empty != nil {
+		fmt.Fprintf(writer, "empty:%d (size 0, never pushed)\n", *sum.Empty)
+		if opts.verbose > 0 {
+			for _, name := range sum.EmptyRepos {
+				fmt.Fprintf(writer, "  empty: %s\n", name)
+			}
+		}
+	}
+	if sum.Protected != nil {
+		fmt.Fprintf(writer, "protected:%d unprotected:%d (default branch)\n", *sum.Protected, len(sum.UnprotectedRepos))
+		if opts.verbose > 0 {
+			for _, name := range sum.UnprotectedRepos {
+				fmt.Fprintf(writer, "  unprotected: %s\n", name)
+			}
+		}
+	}
+	if sum.BotChecked > 0 {
+		fmt.Fprintf(writer, "botOnlyPushes:%d of %d checked (commits in the last %dd all by bots)\n", len(sum.BotOnlyRepos), sum.BotChecked, opts.botDays)
+		if len(sum.BotOnlyRepos) > 0 {
+			fmt.Fprintf(writer, "  botOnlyPushes: %s\n", strings.Join(sum.BotOnlyRepos, ","))
+		}
+	}
+	if opts.bigSize > 0 {
+		fmt.Fprintf(writer, "bigRepos:%d (over %s)\n", len(sum.BigRepos), humanBytes(opts.bigSize))
+		if opts.verbose > 0 {
+			for _, b := range sum.BigRepos {
+				fmt.Fprintf(writer, "  bigRepo: %s %s\n", b.Name, b.Size)
+			}
+		}
+	}
+	if opts.langSize {
+		fmt.Fprintf(writer, "langSize:%d languages by total repo size\n", len(sum.LangSizes))
+		for _, l := range sum.LangSizes {
+			fmt.Fprintf(writer, "  langSize: %s %s (%d repos)\n", l.Language, l.Size, l.Repos)
+		}
+	}
+	if sum.LastRun != nil {
+		fmt.Fprintf(writer, "Changed since last run %v [%d]:\n", truncTime(inLoc(*sum.LastRun, opts.loc), opts.timePrecision), len(sum.ChangedSinceLast))
+		for _, c := range sum.ChangedSinceLast {
+			newTxt := ""
+			if c.New {
+				newTxt = " (new)"
+			}
+			fmt.Fprintf(writer, "  %s PushedAt:%v%s\n", c.Name, truncTime(inLoc(c.PushedAt, opts.loc), opts.timePrecision), newTxt)
+		}
+	}
+
+	var avgIssues float64
+	if sum.Repos > 0 {
+		avgIssues = float64(sum.TotOpenIssues) / float64(sum.Repos)
+	}
+	fmt.Fprintf(writer, "Repos [%d] sorted by %s:\n", listed, bdata.Title())
+	if opts.output == outputPrettyTable {
+		if err := writeBoxTable(writer, prettyTable(bdata, listed, sum, avgIssues, issuesLabel("openIssues"), opts), opts.ascii); err != nil {
+			return err
+		}
+	} else {
+		sep := opts.sep
+		if sep == "" {
+			sep = defSep
+		}
+		for i := 0; i < listed; i++ {
+			var extra []string
+			if opts.span {
+				extra = append(extra, fmt.Sprintf("span:%.0fd", days(bdata.Repo(i).span())))
+			}
+			if opts.ratio {
+				extra = append(extra, fmt.Sprintf("ratio:%.2f", bdata.Repo(i).issueRatio()))
+			}
+			if opts.annotate {
+				extra = append(extra, vsAverage(issuesLabel("openIssues"), bdata.Repo(i).OpenIssuesCount, avgIssues))
+			}
+			for _, f := range opts.fields {
+				extra = append(extra, fmt.Sprintf("%s:%s", fieldLabel(f, opts), truncate(extraFields[f](bdata.Repo(i), opts), opts.fieldWidth)))
+			}
+			if opts.verbose > 0 && bdata.Repo(i).Disabled {
+				extra = append(extra, "[disabled]")
+			}
+			if opts.verbose > 0 && bdata.Repo(i).MirrorURL != "" {
+				extra = append(extra, "[mirror:"+bdata.Repo(i).MirrorURL+"]")
+			}
+			index := fmt.Sprintf("i:%d", i)
+			if sep == defSep {
+				index = fmt.Sprintf("i:%2d", i) // aligned only when space separated
+			}
+			prefix := index + sep + fmt.Sprintf("%s:%v", bdata.FieldName(), bdata.Field(i)) + sep
+			line := strings.Join(append([]string{prefix + truncate(bdata.Name(i), opts.maxNameWidth)}, extra...), sep)
+			if opts.colors != nil {
+				line = colorize(line, colorFor(opts.colors, bdata.Repo(i).PushedAt, time.Now()))
+			}
+			fmt.Fprintln(writer, line)
+			if desc := bdata.Repo(i).Description; opts.showDesc && desc != "" {
+				indent := strings.Repeat(" ", utf8.RuneCountInString(prefix))
+				if opts.wrap < 0 {
+					fmt.Fprintf(writer, "%s%s\n", indent, desc)
+					continue
+				}
+				width := wrapWidth(opts.wrap) - len(indent)
+				if width < 20 {
+					width = 20
+				}
+				for _, line := range wrapText(desc, width) {
+					fmt.Fprintf(writer, "%s%s\n", indent, line)
+				}
+			}
+		}
+	}
+	if !opts.noHeader {
+		fmt.Fprintf(writer, "<endOfReport: %s>\n", reportName)
+	}
+
+	return nil
+}
+
+// defSep - the default -sep between the fields of a text listing line.
+const defSep = " "
+
+// vsAverage - returns label:n annotated with how n compares to avg in
+// percent, an avg of 0 can't be compared to so is just noted.
+func vsAverage(label string, n int, avg float64) string {
+	return label + ":" + vsAverageTxt(n, avg)
+}
+
+// vsAverageTxt - vsAverage without the label.
+func vsAverageTxt(n int, avg float64) string {
+	if avg == 0 {
+		return fmt.Sprintf("%d (avg 0)", n)
+	}
+	return fmt.Sprintf("%d (%+.0f%% vs avg)", n, (float64(n)-avg)/avg*100)
+}
+
+// defHighlight - the summaryMetrics name of the summary's mostWatchersRepo line.
+const defHighlight = "watchers"
+
+// skippedTxt - returns the annotation for a summary count whose repos were skipped.
+func skippedTxt(skipped bool) string {
+	if skipped {
+		return " (skipped)"
+	}
+	return ""
+}
+
+// postReport - POSTs the json report in body to posturl and returns the
+// response status, non 2xx statuses are returned as an error.
+func postReport(ctx context.Context, posturl string, body io.Reader) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", posturl, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res, err := apiClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = res.Body.Close() }()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.Status, fmt.Errorf("POST %s failed status:%s", posturl, res.Status)
+	}
+	return res.Status, nil
+}
+
+// sortTypeOf - returns the sortType selected by the sort flags of fl, erroring
+// when more than one sort field flag is set since only one can apply.
+func sortTypeOf(fl flagsStruct) (sortType, error) {
+	var stype sortType
+	if fl.ascending {
+		stype = sascending
+	}
+	var set []string
+	if fl.bypushedat {
+		stype |= sbyPushedAt
+		set = append(set, "-bypushedat")
+	}
+	if fl.bysubscribers {
+		stype |= sbySubscribers
+		set = append(set, "-bysubscribers")
+	}
+	if fl.byissueratio {
+		stype |= sbyIssueRatio
+		set = append(set, "-byissueratio")
+	}
+	if fl.sortexpr != "" {
+		stype |= sbyExpr
+		set = append(set, "-sortexpr")
+	}
+	if len(set) > 1 {
+		return 0, fmt.Errorf("conflicting sort flags %s, pick one", strings.Join(set, " "))
+	}
+	return stype, nil
+}
+
+// checkConflicts - returns an error for a combination of the flags of fl,
+// whose -output modes are outputs, that can't be used together.
+func checkConflicts(fl flagsStruct, outputs []string) error {
+	switch {
+	case hasString(outputs, outputXLSX) && (fl.outfile == "" || fl.posturl != ""):
+		return errors.New("-output xlsx needs an -outfile and can't be used with -posturl")
+	case (fl.appid != "") != (fl.appkey != "") || (fl.appid != "") != (fl.installationid != ""):
+		return errors.New("-appid, -appkey and -installationid go together")
+	case fl.append && (fl.outfile == "" || hasString(outputs, outputXLSX)):
+		return errors.New("-append needs an -outfile and can't be used with -output xlsx")
+	case len(outputs) > 1 && !strings.Contains(fl.outfile, "{ext}"):
+		return errors.New("several -output modes need an -outfile template with {ext} e.g. report.{ext}")
+	case len(outputs) > 1 && (fl.posturl != "" || fl.rawdump || fl.compare):
+		return errors.New("several -output modes can't be used with -posturl, -rawdump or -compare")
+	case fl.compare && (fl.rawdump || fl.posturl != ""):
+		return errors.New("-compare can't be used with -rawdump or -posturl")
+	case fl.redact && fl.rawdump:
+		return errors.New("-redact can't apply to -rawdump")
+	case fl.flatten && !hasString(outputs, outputCSV) && !hasString(outputs, outputTSCSV):
+		return fmt.Errorf("-flatten requires -output %s or %s", outputCSV, outputTSCSV)
+	case fl.posturl != "" && outputs[0] != outputJSON:
+		return fmt.Errorf("-posturl requires -output %s", outputJSON)
+	case fl.cacert != "" && fl.insecure:
+		return errors.New("-cacert and -insecure can't be used together")
+	}
+	return nil
+}
+
+// runOpts - the settings of a run besides its report's, as main resolved
+// them, for explain.
+type runOpts struct {
+	outfile      string        // -outfile template, "" writes to stdout
+	posturl      string        // -posturl the json report is POSTed to
+	token        bool          // an api token is sent
+	tokenfile    string        // -tokenfile the token was read from
+	appid        string        // -appid the installation token was minted for
+	timeout      time.Duration // -timeout of the whole run, 0 none
+	maxIdleConns int
+	keepAlive    time.Duration
+	maxRedirects int
+	noRedirect   bool
+}
+
+// explain - writes a human readable summary of the effective settings of a
+// run to w, never including the token itself.
+func explain(w io.Writer, urlnames []string, sortby sortType, opts reportOpts, ro runOpts) {
+	fmt.Fprintf(w, "explain: urls: %s\n", strings.Join(urlnames, ","))
+	if opts.fetch.demo {
+		fmt.Fprintf(w, "explain: demo: embedded dataset, no api requests\n")
+	}
+	fmt.Fprintf(w, "explain: sort: %s\n", sorterFor(sortby, ghStruct{}).Title())
+
+	var filters []string
+	if len(opts.include) > 0 {
+		filters = append(filters, "include:"+strings.Join(opts.include, ","))
+	}
+	if len(opts.exclude) > 0 {
+		filters = append(filters, "exclude:"+strings.Join(opts.exclude, ","))
+	}
+	if opts.skipDisabled {
+		filters = append(filters, "skipdisabled")
+	}
+	if opts.skipMirrors {
+		filters = append(filters, "skipmirrors")
+	}
+	if opts.skipEmpty {
+		filters = append(filters, "skipempty")
+	}
+	if len(opts.topicsAll) > 0 {
+		filters = append(filters, "topic(all):"+strings.Join(opts.topicsAll, ","))
+	}
+	if len(opts.topicsAny) > 0 {
+		filters = append(filters, "topicany:"+strings.Join(opts.topicsAny, ","))
+	}
+	if len(opts.languages) > 0 {
+		filters = append(filters, "language:"+strings.Join(opts.languages, ","))
+	}
+	if opts.minAge > 0 {
+		filters = append(filters, fmt.Sprintf("minage:%.0fd", days(opts.minAge)))
+	}
+	if opts.sample > 0 {
+		filters = append(filters, fmt.Sprintf("sample:%d seed:%d", opts.sample, opts.seed))
+	}
+	if opts.top > 0 {
+		filters = append(filters, fmt.Sprintf("top:%d", opts.top))
+	}
+	if len(filters) == 0 {
+		filters = append(filters, "none")
+	}
+	fmt.Fprintf(w, "explain: filters: %s\n", strings.Join(filters, " "))
+
+	output := opts.output
+	if output == "" {
+		output = outputText
+	}
+	for _, o := range opts.moreOutputs {
+		output += "," + o.output
+	}
+	dest := "stdout"
+	if ro.outfile != "" {
+		dest = "outfile:" + ro.outfile
+	}
+	if ro.posturl != "" {
+		dest = "posturl:" + ro.posturl
+	}
+	fmt.Fprintf(w, "explain: output: %s to %s\n", output, dest)
+
+	auth := "no"
+	if ro.token {
+		auth = "yes from $" + tokenEnv
+		if ro.tokenfile != "" {
+			auth = "yes from -tokenfile " + ro.tokenfile
+		}
+		if ro.appid != "" {
+			auth = "yes installation token of -appid " + ro.appid
+		}
+	}
+	fmt.Fprintf(w, "explain: auth token: %s\n", auth)
+	timeout := "none"
+	if ro.timeout > 0 {
+		timeout = ro.timeout.String()
+	}
+	fmt.Fprintf(w, "explain: timeout: %s\n", timeout)
+	fmt.Fprintf(w, "explain: connections: maxidleconns:%d keepalive:%v maxredirects:%d noredirect:%t\n",
+		ro.maxIdleConns, ro.keepAlive, ro.maxRedirects, ro.noRedirect)
+	perPage := fmt.Sprintf("%d (api default)", defPerPage)
+	if opts.fetch.perPage > 0 {
+		perPage = strconv.Itoa(opts.fetch.perPage)
+	}
+	fmt.Fprintf(w, "explain: page size: %s startpage:%d pagedelay:%v\n", perPage, opts.fetch.startPage, opts.fetch.pageDelay)
+}
+
+type flagsStruct struct {
+	showVersion    bool
+	verbose        int
+	ghurl          string
+	ascending      bool
+	bypushedat     bool
+	tz             string
+	timeprecision  string
+	cpuprofile     string
+	ascii          bool
+	showowner      bool
+	mergestrategy  string
+	showratelimit  bool
+	botcheck       int
+	botdays        int
+	bots           string
+	memprofile     string
+	skipdisabled   bool
+	skipmirrors    bool
+	output         string
+	posturl        string
+	timeout        time.Duration
+	top            int
+	span           bool
+	subscribers    bool
+	bysubscribers  bool
+	noheader       bool
+	groupby        string
+	perpage        int
+	demo           bool
+	byissueratio   bool
+	ratio          bool
+	includeempty   bool
+	fields         string
+	fieldwidth     int
+	startpage      int
+	pagedelay      time.Duration
+	sample         int
+	seed           int64
+	orginfo        bool
+	topic          string
+	topicany       string
+	language       string
+	promrepos      bool
+	policy         string
+	strict         bool
+	staleness      bool
+	stalebuckets   string
+	issuebuckets   string
+	histwidth      int
+	maxnamewidth   int
+	summaryfirst   bool
+	team           string
+	issuesprs      bool
+	outfile        string
+	appid          string
+	appkey         string
+	installationid string
+	append         bool
+	bufsize        int
+	color          bool
+	colorscheme    string
+	relative       bool
+	explain        bool
+	rawdump        bool
+	compare        bool
+	maxidleconns   int
+	cacert         string
+	insecure       bool
+	keepalive      time.Duration
+	title          string
+	exclude        string
+	excludefile    string
+	include        string
+	includefile    string
+	includewarn    bool
+	flatten        bool
+	tokenfile      string
+	redact         bool
+	ratestate      string
+	ratewait       bool
+	rateforce      bool
+	summaryjson    string
+	dedup          bool
+	showdesc       bool
+	wrap           int
+	releases       bool
+	protection     bool
+	minage         string
+	verifystars    int
+	sortexpr       string
+	besteffort     bool
+	annotate       bool
+	sincelastrun   string
+	noredirect     bool
+	maxredirects   int
+	likelydead     bool
+	deaddays       int
+	bigrepos       bool
+	langsize       bool
+	notopics       bool
+	sep            string
+	empty          bool
+	skipempty      bool
+	bigsize        string
+	silent         bool
+	features       bool
+	requirelicense bool
+	maxopenissues  int
+	highlight      string
+	tiebreak       string
+}
+
+// example of organization github api repos url : "https://api.github.com/orgs/gorilla/repos"
+// example of users        github api repos url: "https://api.github.com/users/phcurtis/repos"
+
+var flags = flagsStruct{}
+
+const ghurlDef = "https://api.github.com/users/phcurtis/repos"
+
+func init() {
+	flag.StringVar(&flags.ghurl, "ghurl", ghurlDef, "github url(s), comma separated, for getting repos info")
+	flag.StringVar(&flags.fields, "fields", "", "extra per repo columns, comma separated: "+strings.Join(extraFieldNames(), ","))
+	flag.BoolVar(&flags.showdesc, "showdesc", false, "show repo descriptions under their names in text output")
+	flag.IntVar(&flags.wrap, "wrap", 0, "wrap -showdesc descriptions to this width, 0 uses $COLUMNS or 80, negative doesn't wrap")
+	flag.IntVar(&flags.maxnamewidth, "maxnamewidth", 0, "truncate repo names in the text listing to this width, 0 no limit")
+	flag.IntVar(&flags.fieldwidth, "fieldwidth", 0, "truncate extra column values in text output to this width, 0 no limit")
+	flag.BoolVar(&flags.dedup, "dedup", false, "drop repos repeated by id, e.g. overlapping pages or -ghurl lists, before summarizing")
+	flag.StringVar(&flags.mergestrategy, "mergestrategy", defMergeStrategy, "copy of a repo -dedup keeps: newest (pushed_at), stars (most) or first (seen)")
+	flag.StringVar(&flags.include, "include", "", "keep only these comma separated repo names (full_name when several -ghurl or -showowner), applied before -exclude")
+	flag.StringVar(&flags.includefile, "includefile", "", "file of repo names to keep, one per line, # starts a comment")
+	flag.BoolVar(&flags.includewarn, "includewarn", false, "warn instead of failing when -include names aren't found")
+	flag.StringVar(&flags.exclude, "exclude", "", "drop these comma separated repo names (full_name when several -ghurl or -showowner)")
+	flag.StringVar(&flags.excludefile, "excludefile", "", "file of repo names to drop, one per line, # starts a comment")
+	flag.StringVar(&flags.minage, "minage", "", "keep only repos created at least this long ago, in days e.g. 90d or a duration e.g. 36h")
+	flag.StringVar(&flags.topic, "topic", "", "keep only repos having ALL of these comma separated topics")
+	flag.StringVar(&flags.topicany, "topicany", "", "keep only repos having ANY of these comma separated topics")
+	flag.StringVar(&flags.language, "language", "", "keep only repos whose primary language is ANY of these comma separated languages")
+	flag.BoolVar(&flags.includeempty, "includeempty", false, "with -groupby owner also list owners that have no repos")
+	flag.BoolVar(&flags.rawdump, "rawdump", false, "write the raw api repo objects as one json array without summarizing, -verbose adds page comments")
+	flag.BoolVar(&flags.compare, "compare", false, "write a table comparing each -ghurl source's repos, open issues and stars, unfiltered, instead of the report")
+	flag.BoolVar(&flags.demo, "demo", false, "report on an embedded demo dataset without any api requests")
+	flag.StringVar(&flags.tokenfile, "tokenfile", "", "file holding a GitHub token, takes precedence over $"+tokenEnv)
+	flag.StringVar(&flags.appid, "appid", "", "authenticate as this GitHub App's -installationid, minting an installation token with the -appkey, takes precedence over -tokenfile; an installation token minted elsewhere works as a -tokenfile or $"+tokenEnv)
+	flag.StringVar(&flags.appkey, "appkey", "", "PEM file holding the -appid's private key")
+	flag.StringVar(&flags.installationid, "installationid", "", "installation id of the -appid on the org or user reported on")
+	flag.StringVar(&flags.team, "team", "", "report on a team's repos given as org/team instead of -ghurl, needs a token in $"+tokenEnv)
+	flag.StringVar(&flags.groupby, "groupby", "", "print sub-totals per group before the totals: owner")
+	flag.BoolVar(&flags.silent, "silent", false, "write nothing to stdout, only errors to stderr, for exit status checks e.g. with -policy")
+	flag.BoolVar(&flags.showVersion, "version", false, "show version")
+	flag.BoolVar(&flags.explain, "explain", false, "print the effective settings to stderr before running")
+	flag.IntVar(&flags.verbose, "verbose", 0, "verbose level")
+	flag.BoolVar(&flags.ascending, "ascending", false, "sort ascending")
+	flag.BoolVar(&flags.bypushedat, "bypushedat", false, "sort bypushedat field")
+	flag.BoolVar(&flags.bysubscribers, "bysubscribers", false, "sort bysubscribers field (implies -subscribers)")
+	flag.StringVar(&flags.sortexpr, "sortexpr", "", "sort by a text/template of each repo e.g. '{{len .Name}}' or '{{days .PushedAt}}', numerically when all values are numbers")
+	flag.BoolVar(&flags.byissueratio, "byissueratio", false, "sort by open issues to watchers ratio")
+	flag.BoolVar(&flags.annotate, "annotate", false, "annotate each listed repo's open issues relative to the average e.g. +120% vs avg")
+	flag.BoolVar(&flags.ratio, "ratio", false, "show per repo open issues to watchers ratio")
+	flag.BoolVar(&flags.subscribers, "subscribers", false, "get subscribers_count (true watchers, watchers_count is stars) with a request per repo")
+	flag.IntVar(&flags.verifystars, "verifystars", 0, "re-check the star counts of the N most starred repos against the repo endpoint, a request each")
+	flag.IntVar(&flags.botcheck, "botcheck", 0, "check the commits of the N most recently pushed repos, a request each, flagging those whose recent commits are all by bots")
+	flag.IntVar(&flags.botdays, "botdays", 90, "days of recent commits -botcheck looks at")
+	flag.StringVar(&flags.bots, "bots", defBots, "comma separated logins -botcheck counts as bots, besides logins ending in [bot] and app accounts")
+	flag.BoolVar(&flags.releases, "releases", false, "get and show each repo's release count with a request per repo, honors -pagedelay")
+	flag.BoolVar(&flags.protection, "protection", false, "get and show whether each repo's default branch is protected, a request per repo needing a token with admin access, honors -pagedelay, -verbose lists the unprotected")
+	flag.BoolVar(&flags.skipdisabled, "skipdisabled", false, "skip repos disabled by GitHub")
+	flag.BoolVar(&flags.skipmirrors, "skipmirrors", false, "skip mirror repos (mirror_url set)")
+	flag.BoolVar(&flags.skipempty, "skipempty", false, "skip empty repos, see -empty")
+	flag.StringVar(&flags.output, "output", outputText, "output mode: text, json, yaml, ndjson, csv, names, prometheus, counts (repos open_issues total_stars total_forks on one line) xlsx (needs an -outfile) tscsv (csv with a leading run_at column) prettytable (text with the repos in a bordered table, see -ascii), metrics (a table of every sortable metric per repo, or the -fields) or histogram (repos by open issues, see -issuebuckets), several comma separated need an -outfile with {ext}")
+	flag.BoolVar(&flags.redact, "redact", false, "replace private repo names with private-repo-N keeping their metrics, for sharing reports")
+	flag.BoolVar(&flags.flatten, "flatten", false, "with -output csv append the total open issues and repo count to every row")
+	flag.BoolVar(&flags.orginfo, "orginfo", false, "show org name, public repo count, blog and location in the header for org urls")
+	flag.StringVar(&flags.title, "title", defReportName, "report name shown in the header and trailer and in the json report field")
+	flag.StringVar(&flags.highlight, "highlight", defHighlight, "metric of the summary's most repo line: "+strings.Join(summaryMetricNames(), ","))
+	flag.StringVar(&flags.tiebreak, "tiebreak", defTieBreak, "order of repos tied on the summary's most repo lines: "+strings.Join(tieBreakNames(), ","))
+	flag.BoolVar(&flags.noheader, "noheader", false, "suppress the report header and endOfReport trailer lines")
+	flag.StringVar(&flags.sep, "sep", defSep, `separator between the fields of -output text repo lines e.g. "|" or "\t" for a tab, a lighter option than csv`)
+	flag.BoolVar(&flags.features, "features", false, "show how many repos have issues, wiki and projects enabled and repos with issues disabled but open issues")
+	flag.BoolVar(&flags.likelydead, "likelydead", false, "count repos with no watchers, no open issues and no push in -deaddays, -verbose lists them")
+	flag.IntVar(&flags.deaddays, "deaddays", 365, "days without a push for -likelydead")
+	flag.BoolVar(&flags.bigrepos, "bigrepos", false, "count repos over -bigsize, -verbose lists them with their sizes")
+	flag.BoolVar(&flags.langsize, "langsize", false, "list primary languages by the total size of their repos, biggest first, repos without one as "+noLanguage)
+	flag.StringVar(&flags.bigsize, "bigsize", "1GB", "size for -bigrepos e.g. 1GB or 512MiB, units are binary")
+	flag.BoolVar(&flags.notopics, "notopics", false, "count and list the repos without any topic, to find ones needing tagging")
+	flag.BoolVar(&flags.empty, "empty", false, "count empty repos, size 0 and never pushed (pushed_at null or not after created_at), placeholders to clean up, -verbose lists them")
+	flag.BoolVar(&flags.staleness, "staleness", false, "summarize repo counts by days since last push")
+	flag.StringVar(&flags.stalebuckets, "stalebuckets", defStaleBuckets, "ascending -staleness bucket edges in days")
+	flag.StringVar(&flags.issuebuckets, "issuebuckets", defIssueBuckets, "ascending -output histogram open issues bucket upper edges, the default buckets 0, 1-5, 6-20 and 21+")
+	flag.IntVar(&flags.histwidth, "histwidth", 0, "width of -output histogram lines, the biggest bucket's bar filling it, 0 uses $COLUMNS or 80")
+	flag.BoolVar(&flags.issuesprs, "issuesprs", false, "label open issue counts as open issues+prs, as GitHub counts open pull requests in them")
+	flag.BoolVar(&flags.span, "span", false, "show per repo creation to last push span and the average span")
+	flag.IntVar(&flags.perpage, "perpage", 0, "repos per api page request (max 100), 0 uses the api default of 30")
+	flag.DurationVar(&flags.pagedelay, "pagedelay", 0, "wait between page requests e.g. 1s, helps with secondary rate limits")
+	flag.BoolVar(&flags.besteffort, "besteffort", false, "skip pages that fail to parse, logging them and marking the report partial, instead of failing")
+	flag.BoolVar(&flags.strict, "strict", false, "error when repo objects lack name or timestamp fields instead of using zero values")
+	flag.IntVar(&flags.startpage, "startpage", 1, "page to start pagination at, to resume an interrupted scan")
+	flag.IntVar(&flags.sample, "sample", 0, "report on a random sample of N repos, 0 reports on all")
+	flag.Int64Var(&flags.seed, "seed", 0, "seed for -sample to reproduce a sample, 0 picks one from the time")
+	flag.IntVar(&flags.top, "top", 0, "list only the first N repos after sorting, 0 lists all")
+	flag.IntVar(&flags.maxopenissues, "maxopenissues", -1, "policy max open issues per repo, violations link the repo's issues page, -1 none")
+	flag.BoolVar(&flags.requirelicense, "requirelicense", false, "list public repos without a license as policy violations, exiting 2 if any")
+	flag.StringVar(&flags.policy, "policy", "", "json file of per metric limits e.g. {\"open_issues\":{\"max\":50}}, exits 2 listing violating repos")
+	flag.BoolVar(&flags.summaryfirst, "summaryfirst", false, "with -output ndjson write the summary line first to stdout instead of to stderr")
+	flag.BoolVar(&flags.promrepos, "promrepos", false, "add per repo gauges to -output prometheus")
+	flag.StringVar(&flags.sincelastrun, "sincelastrun", "", "state file of each repo's pushed_at, lists repos pushed since the previous run then updates it")
+	flag.StringVar(&flags.summaryjson, "summaryjson", "", "also write just the totals as json to this file, whatever the -output")
+	flag.StringVar(&flags.outfile, "outfile", "", "write the report to this file, replaced atomically, instead of stdout, {ext} is replaced by the output mode's extension")
+	flag.BoolVar(&flags.append, "append", false, "append to the -outfile instead of replacing it, after a # line with the run's time in the text like modes and without repeating the csv header, e.g. a log of -output counts runs, not with xlsx")
+	flag.IntVar(&flags.bufsize, "bufsize", defBufSize, "report output buffer size in bytes, 0 writes unbuffered")
+	flag.BoolVar(&flags.color, "color", false, "color text output repo lines by push age per -colorscheme")
+	flag.StringVar(&flags.colorscheme, "colorscheme", "", "-color rules name=age:color comma separated, each coloring repos pushed within its age, older get the last, \"\" means "+defColorScheme)
+	flag.BoolVar(&flags.relative, "relative", false, "add pushed_age_seconds and updated_age_seconds, as of the report, to each repo of json, yaml and ndjson output")
+	flag.StringVar(&flags.posturl, "posturl", "", "POST the json report to this url instead of writing it to stdout")
+	flag.DurationVar(&flags.timeout, "timeout", 0, "overall timeout for the run, 0 means no timeout")
+	flag.IntVar(&flags.maxidleconns, "maxidleconns", defMaxIdlePerHost, "max idle connections kept per host")
+	flag.StringVar(&flags.cacert, "cacert", "", "pem file of extra CA certificates to trust, e.g. for a GitHub Enterprise internal CA")
+	flag.BoolVar(&flags.insecure, "insecure", false, "don't verify TLS certificates, for testing only")
+	flag.DurationVar(&flags.keepalive, "keepalive", defKeepAlive, "tcp keep-alive interval, negative disables keep-alive connections")
+	flag.StringVar(&flags.ratestate, "ratestate", "", "file remembering a rate limit reset between runs, runs before the reset refuse to start")
+	flag.BoolVar(&flags.ratewait, "ratewait", false, "wait for rate limit resets, one remembered by -ratestate or hit during the run, and secondary limit Retry-Afters instead of failing")
+	flag.BoolVar(&flags.showratelimit, "showratelimit", false, "just print the remaining rate limit and its reset from the api's /rate_limit, no report, -verbose prints it after a report")
+	flag.BoolVar(&flags.rateforce, "rateforce", false, "with -ratestate start even before a remembered reset")
+	flag.BoolVar(&flags.noredirect, "noredirect", false, "fail on redirects, e.g. from a renamed user/org, instead of following them")
+	flag.IntVar(&flags.maxredirects, "maxredirects", defMaxRedirects, "follow at most this many redirects per request, -verbose logs each")
+	flag.StringVar(&flags.tz, "tz", "", "time zone for displayed timestamps e.g. Local, UTC, America/New_York")
+	flag.StringVar(&flags.timeprecision, "timeprecision", "", "truncate text and csv timestamps to: "+strings.Join(timePrecisions, ",")+", json keeps full precision")
+	flag.StringVar(&flags.cpuprofile, "cpuprofile", "", "write a pprof cpu profile of the run to this file")
+	flag.BoolVar(&flags.ascii, "ascii", false, "draw -output prettytable borders with ascii characters instead of box drawing ones")
+	flag.BoolVar(&flags.showowner, "showowner", false, "display repos as owner/name even when there's a single -ghurl")
+	flag.StringVar(&flags.memprofile, "memprofile", "", "write a pprof heap profile at the end of the run to this file")
+}
+
+// stdout - where anything but errors and -explain is written, discarded with -silent.
+var stdout io.Writer = os.Stdout
+
+// defBufSize - the default -bufsize.
+const defBufSize = 64 * 1024
+
+func main() {
+	flag.Parse()
+	if flags.silent {
+		stdout = ioutil.Discard
+	}
+	if flag.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unrecognized %v\nUsage of ./%s:\n",
+			flag.Args(), filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	if flags.verbose > 0 {
+		fmt.Fprintf(stdout, "%v version:%s\n", os.Args, version)
+	}
+	if flags.showVersion {
+		fmt.Fprintf(stdout, "./%s version=%s\n", filepath.Base(os.Args[0]), version)
+	}
+	stype, err := sortTypeOf(flags)
+	if err != nil {
+		log.Fatalf("%s: %v\n", os.Args, err)
+	}
+
+	opts := reportOpts{
+		skipDisabled: flags.skipdisabled,
+		skipEmpty:    flags.skipempty,
+		skipMirrors:  flags.skipmirrors,
+		verbose:      flags.verbose,
+		output:       flags.output,
+		top:          flags.top,
+		span:         flags.span,
+		subscribers:  flags.subscribers,
+		noHeader:     flags.noheader,
+		groupBy:      flags.groupby,
+		fetch: fetchOpts{
+			perPage:    flags.perpage,
+			demo:       flags.demo,
+			startPage:  flags.startpage,
+			verbose:    flags.verbose,
+			pageDelay:  flags.pagedelay,
+			strict:     flags.strict,
+			bestEffort: flags.besteffort,
+		},
+		ratio:          flags.ratio,
+		includeEmpty:   flags.includeempty,
+		fieldWidth:     flags.fieldwidth,
+		sample:         flags.sample,
+		seed:           flags.seed,
+		orgInfo:        flags.orginfo,
+		topicsAll:      splitList(flags.topic),
+		topicsAny:      splitList(flags.topicany),
+		languages:      splitList(flags.language),
+		promRepos:      flags.promrepos,
+		maxNameWidth:   flags.maxnamewidth,
+		summaryFirst:   flags.summaryfirst,
+		issuesPRs:      flags.issuesprs,
+		title:          flags.title,
+		flatten:        flags.flatten,
+		redact:         flags.redact,
+		summaryJSON:    flags.summaryjson,
+		dedup:          flags.dedup,
+		showDesc:       flags.showdesc,
+		wrap:           flags.wrap,
+		histWidth:      flags.histwidth,
+		releases:       flags.releases,
+		protection:     flags.protection,
+		verifyStars:    flags.verifystars,
+		annotate:       flags.annotate,
+		sinceLastRun:   flags.sincelastrun,
+		silent:         flags.silent,
+		highlight:      flags.highlight,
+		tiebreak:       flags.tiebreak,
+		relative:       flags.relative,
+		noTopics:       flags.notopics,
+		langSize:       flags.langsize,
+		sep:            flags.sep,
+		empty:          flags.empty,
+		ascii:          flags.ascii,
+		showOwner:      flags.showowner,
+		mergeStrategy:  flags.mergestrategy,
+		features:       flags.features,
+		requireLicense: flags.requirelicense,
+	}
+	if flags.likelydead {
+		opts.deadDays = flags.deaddays
+		if opts.deadDays <= 0 {
+			log.Fatalf("%s: invalid -deaddays %d\n", os.Args, flags.deaddays)
+		}
+	}
+	if flags.color || flags.colorscheme != "" {
+		if opts.colors, err = parseColorScheme(flags.colorscheme); err != nil {
+			log.Fatalf("%s: invalid -colorscheme: %v\n", os.Args, err)
+		}
+	}
+	if flags.botcheck < 0 || flags.botcheck > 0 && flags.botdays <= 0 {
+		log.Fatalf("%s: invalid -botcheck %d or -botdays %d\n", os.Args, flags.botcheck, flags.botdays)
+	}
+	opts.botCheck, opts.botDays, opts.bots = flags.botcheck, flags.botdays, splitList(flags.bots)
+	if flags.bufsize < 0 {
+		log.Fatalf("%s: invalid -bufsize %d\n", os.Args, flags.bufsize)
+	}
+	if flags.bigrepos {
+		if opts.bigSize, err = parseSize(flags.bigsize); err != nil || opts.bigSize == 0 {
+			log.Fatalf("%s: invalid -bigsize %q\n", os.Args, flags.bigsize)
+		}
+	}
+	if opts.seed == 0 {
+		opts.seed = time.Now().UnixNano()
+	}
+	if opts.include, err = nameList(flags.include, flags.includefile); err != nil {
+		log.Fatalf("%s: -includefile err:%v\n", os.Args, err)
+	}
+	opts.includeWarn = flags.includewarn
+	if opts.exclude, err = nameList(flags.exclude, flags.excludefile); err != nil {
+		log.Fatalf("%s: -excludefile err:%v\n", os.Args, err)
+	}
+	if opts.fields, err = parseFields(flags.fields); err != nil {
+		log.Fatalf("%s: invalid -fields: %v\n", os.Args, err)
+	}
+	if flags.sortexpr != "" {
+		if opts.sortExpr, err = parseSortExpr(flags.sortexpr); err != nil {
+			log.Fatalf("%s: invalid -sortexpr: %v\n", os.Args, err)
+		}
+	}
+	if flags.minage != "" {
+		if opts.minAge, err = parseAge(flags.minage); err != nil {
+			log.Fatalf("%s: invalid -minage: %v\n", os.Args, err)
+		}
+	}
+	if opts.releases && !hasString(opts.fields, "releases") {
+		opts.fields = append(opts.fields, "releases")
+	}
+	if opts.protection && !hasString(opts.fields, "protected") {
+		opts.fields = append(opts.fields, "protected")
+	}
+	if flags.staleness {
+		if opts.staleBuckets, err = parseBuckets(flags.stalebuckets, 1); err != nil {
+			log.Fatalf("%s: invalid -stalebuckets: %v\n", os.Args, err)
+		}
+	}
+	if opts.issueBuckets, err = parseBuckets(flags.issuebuckets, 0); err != nil {
+		log.Fatalf("%s: invalid -issuebuckets: %v\n", os.Args, err)
+	}
+	if flags.histwidth < 0 {
+		log.Fatalf("%s: invalid -histwidth %d\n", os.Args, flags.histwidth)
+	}
+	if flags.policy != "" {
+		if opts.policy, err = loadPolicy(flags.policy); err != nil {
+			log.Fatalf("%s: invalid -policy: %v\n", os.Args, err)
+		}
+	}
+	if flags.maxopenissues >= 0 {
+		// overrides any -policy open_issues max.
+		if opts.policy == nil {
+			opts.policy = policyStruct{}
+		}
+		limit := opts.policy["open_issues"]
+		max := float64(flags.maxopenissues)
+		limit.Max = &max
+		opts.policy["open_issues"] = limit
+	}
+	if _, ok := summaryMetrics[opts.highlight]; !ok {
+		log.Fatalf("%s: invalid -highlight %q valid:%s\n", os.Args, opts.highlight, strings.Join(summaryMetricNames(), ","))
+	}
+	if _, ok := mergeStrategies[opts.mergeStrategy]; !ok {
+		log.Fatalf("%s: invalid -mergestrategy %q valid:%s\n", os.Args, opts.mergeStrategy, strings.Join(mergeStrategyNames(), ","))
+	}
+	if _, ok := tieBreaks[opts.tiebreak]; !ok {
+		log.Fatalf("%s: invalid -tiebreak %q valid:%s\n", os.Args, opts.tiebreak, strings.Join(tieBreakNames(), ","))
+	}
+	outputs, err := parseOutputs(flags.output)
+	if err != nil {
+		log.Fatalf("%s: invalid -output: %v\n", os.Args, err)
+	}
+	opts.output = outputs[0]
+	if err = checkConflicts(flags, outputs); err != nil {
+		log.Fatalf("%s: %v\n", os.Args, err)
+	}
+	if s, err := strconv.Unquote(`"` + flags.sep + `"`); err == nil {
+		opts.sep = s // e.g. \t
+	}
+	if opts.sep == "" {
+		log.Fatalf("%s: -sep can't be empty\n", os.Args)
+	}
+	switch opts.groupBy {
+	case "", groupByOwner:
+	default:
+		log.Fatalf("%s: invalid -groupby %q\n", os.Args, opts.groupBy)
+	}
+	if flags.tz != "" {
+		loc, err := time.LoadLocation(flags.tz)
+		if err != nil {
+			log.Fatalf("%s: invalid -tz %q: err:%v\n", os.Args, flags.tz, err)
+		}
+		opts.loc = loc
+	}
+	if flags.timeprecision != "" && !hasString(timePrecisions, flags.timeprecision) {
+		log.Fatalf("%s: invalid -timeprecision %q valid:%s\n", os.Args, flags.timeprecision, strings.Join(timePrecisions, ","))
+	}
+	opts.timePrecision = flags.timeprecision
+
+	if flags.maxidleconns < 0 {
+		log.Fatalf("%s: invalid -maxidleconns %d\n", os.Args, flags.maxidleconns)
+	}
+	tlsConf, err := tlsConfig(flags.cacert, flags.insecure)
+	if err != nil {
+		log.Fatalf("%s: -cacert err:%v\n", os.Args, err)
+	}
+	if flags.insecure {
+		log.Printf("%s: WARNING -insecure: TLS certificates are NOT verified, anyone on the network path can read the token and forge responses, only use it for testing\n", os.Args)
+	}
+	apiClient = newAPIClient(flags.maxidleconns, flags.keepalive, tlsConf)
+	if flags.maxredirects < 0 {
+		log.Fatalf("%s: invalid -maxredirects %d\n", os.Args, flags.maxredirects)
+	}
+	maxRedirects := flags.maxredirects
+	if flags.noredirect {
+		maxRedirects = 0
+	}
+	apiClient.CheckRedirect = redirectPolicy(maxRedirects, flags.verbose)
+	apiRate = &rateGate{wait: flags.ratewait, verbose: flags.verbose}
+
+	ctx := context.Background()
+	if flags.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, flags.timeout)
+		defer cancel()
+	}
+	if flags.ratestate != "" && !flags.demo && !flags.rateforce {
+		st, err := loadRateState(flags.ratestate)
+		if err != nil {
+			log.Fatalf("%s: -ratestate err:%v\n", os.Args, err)
+		}
+		if wait := time.Until(st.Reset); wait > 0 {
+			if !flags.ratewait {
+				log.Fatalf("%s: rate limited until %v per %s, use -ratewait or -rateforce\n",
+					os.Args, st.Reset, flags.ratestate)
+			}
+			if flags.verbose > 0 {
+				log.Printf("%s: waiting %v for the rate limit reset\n", os.Args, wait.Round(time.Second))
+			}
+			select {
+			case <-ctx.Done():
+				log.Fatalf("%s: err:%v\n", os.Args, ctx.Err())
+			case <-time.After(wait):
+			}
+		}
+	}
+
+	var writer = stdout
+	var postbuf bytes.Buffer
+	var outfiles []*atomicFile
+	var appendRows []bool
+	if flags.outfile != "" {
+		for _, o := range outputs {
+			var outfile *atomicFile
+			if flags.append {
+				outfile = createAppend(outfileName(flags.outfile, o), appendSeparator(o, time.Now()))
+				appendRows = append(appendRows, appendsRows(o, outfileName(flags.outfile, o)))
+			} else {
+				outfile, err = createAtomic(outfileName(flags.outfile, o))
+			}
+			if err != nil {
+				for _, f := range outfiles {
+					f.abort()
+				}
+				log.Fatalf("%s: -outfile err:%v\n", os.Args, err)
+			}
+			outfiles = append(outfiles, outfile)
+		}
+		writer = outfiles[0]
+		for i, o := range outputs[1:] {
+			opts.moreOutputs = append(opts.moreOutputs, reportOutput{o, outfiles[i+1], flags.append && appendRows[i+1]})
+		}
+		opts.appendRows = flags.append && appendRows[0]
+	}
+	if flags.posturl != "" {
+		writer = &postbuf
+		if outfiles != nil {
+			writer = io.MultiWriter(&postbuf, outfiles[0])
+		}
+	}
+	var bufs []*bufio.Writer
+	if flags.bufsize > 0 {
+		bufs = append(bufs, bufio.NewWriterSize(writer, flags.bufsize))
+		writer = bufs[0]
+		for i := range opts.moreOutputs {
+			bufs = append(bufs, bufio.NewWriterSize(opts.moreOutputs[i].w, flags.bufsize))
+			opts.moreOutputs[i].w = bufs[len(bufs)-1]
+		}
+	}
+
+	apiToken = os.Getenv(tokenEnv)
+	if flags.tokenfile != "" {
+		if apiToken, err = readTokenFile(flags.tokenfile); err != nil {
+			log.Fatalf("%s: -tokenfile err:%v\n", os.Args, err)
+		}
+	}
+	if flags.appid != "" {
+		if apiToken, err = mintInstallationToken(ctx, strings.Split(flags.ghurl, ",")[0], flags.appid, flags.appkey, flags.installationid); err != nil {
+			log.Fatalf("%s: -appid err:%v\n", os.Args, err)
+		}
+	}
+
+	urlnames := strings.Split(flags.ghurl, ",")
+	if flags.team != "" {
+		turl, err := teamURL(flags.team)
+		if err != nil {
+			log.Fatalf("%s: invalid -team: %v\n", os.Args, err)
+		}
+		if apiToken == "" {
+			log.Printf("%s: warning -team normally needs a token in $%s\n", os.Args, tokenEnv)
+		}
+		urlnames = []string{turl}
+	}
+	if flags.demo {
+		urlnames = []string{demoName}
+	}
+	if flags.explain {
+		explain(os.Stderr, urlnames, stype, opts, runOpts{
+			outfile:      flags.outfile,
+			posturl:      flags.posturl,
+			token:        apiToken != "",
+			tokenfile:    flags.tokenfile,
+			appid:        flags.appid,
+			timeout:      flags.timeout,
+			maxIdleConns: flags.maxidleconns,
+			keepAlive:    flags.keepalive,
+			maxRedirects: flags.maxredirects,
+			noRedirect:   flags.noredirect,
+		})
+	}
+	if err = startProfiles(flags.cpuprofile, flags.memprofile); err != nil {
+		log.Fatalf("%s: -cpuprofile err:%v\n", os.Args, err)
+	}
+	defer stopProfiles()
+	// fatalf - log.Fatalf once profiling started, which skips the deferred
+	// stopProfiles, so the profiles are written out first.
+	fatalf := func(format string, v ...interface{}) {
+		stopProfiles()
+		log.Fatalf(format, v...)
+	}
+	if flags.showratelimit {
+		st, err := getRateLimit(ctx, urlnames[0])
+		if err != nil {
+			fatalf("%s: -showratelimit err:%v\n", os.Args, err)
+		}
+		fmt.Fprintf(stdout, "rateLimit: %v\n", st)
+		return
+	}
+	if flags.rawdump {
+		err = rawDump(ctx, urlnames, writer, opts.fetch)
+	} else if flags.compare {
+		err = compareSources(ctx, urlnames, writer, opts.fetch)
+	} else {
+		err = gitHubReposReportSummary(ctx, urlnames, writer, stype, opts)
+	}
+	var pe *policyError
+	if errors.As(err, &pe) {
+		err = nil
+	}
+	var rle *rateLimitError
+	if errors.As(err, &rle) && flags.ratestate != "" {
+		if serr := saveRateState(flags.ratestate, rle); serr != nil {
+			log.Printf("%s: -ratestate err:%v\n", os.Args, serr)
+		}
+	}
+	if rle != nil && rle.secondary {
+		log.Printf("%s: hit GitHub's secondary rate limit, slow down e.g. -pagedelay 1s and retry later\n", os.Args)
+	}
+	for _, b := range bufs {
+		if ferr := b.Flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	if err != nil {
+		for _, f := range outfiles {
+			f.abort()
+		}
+		fatalf("%s: err:%v\n", os.Args, err)
+	}
+	if requests := atomic.LoadInt64(&apiStats.requests); flags.verbose > 0 && requests > 0 {
+		log.Printf("%s: api requests:%d downloaded:%s decoded:%s\n", os.Args, requests,
+			humanBytes(atomic.LoadInt64(&apiStats.wireBytes)), humanBytes(atomic.LoadInt64(&apiStats.bodyBytes)))
+		if st, ok := apiRate.lastStatus(); ok {
+			log.Printf("%s: rate limit %v\n", os.Args, st)
+		}
+	}
+	for _, f := range outfiles {
+		if err = f.commit(); err != nil {
+			fatalf("%s: -outfile err:%v\n", os.Args, err)
+		}
+	}
+
+	if flags.posturl != "" {
+		status, err := postReport(ctx, flags.posturl, &postbuf)
+		if err != nil {
+			fatalf("%s: err:%v\n", os.Args, err)
+		}
+		fmt.Fprintf(stdout, "posted report to %s status:%s\n", flags.posturl, status)
+	}
+
+	if pe != nil {
+		if !flags.silent {
+			for _, v := range pe.violations {
+				fmt.Fprintln(os.Stderr, v)
+			}
+		}
+		stopProfiles()
+		os.Exit(2)
 	}
 }
@@ -0,0 +1,839 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the testdata golden files")
+
+// demoOpts - the reportOpts of a -demo run in output mode output.
+func demoOpts(output string) reportOpts {
+	return reportOpts{
+		output:    output,
+		fetch:     fetchOpts{demo: true},
+		highlight: defHighlight,
+		tiebreak:  defTieBreak,
+	}
+}
+
+// checkGolden - compares got with testdata/name, rewriting it with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	file := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(file, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs, got:\n%s", file, got)
+	}
+}
+
+func TestReportGolden(t *testing.T) {
+	for _, tc := range []struct{ output, golden string }{
+		{outputJSON, "demo.json"},
+		{outputText, "demo.txt"},
+		{outputMetrics, "demo.metrics.txt"},
+	} {
+		var buf bytes.Buffer
+		if err := gitHubReposReportSummary(context.Background(), []string{demoName}, &buf, sdefault, demoOpts(tc.output)); err != nil {
+			t.Fatalf("%s err:%v", tc.output, err)
+		}
+		checkGolden(t, tc.golden, buf.Bytes())
+	}
+}
+
+func TestMetricsSubscribers(t *testing.T) {
+	for _, subscribers := range []bool{false, true} {
+		opts := demoOpts(outputMetrics)
+		opts.subscribers = subscribers
+		var buf bytes.Buffer
+		if err := gitHubReposReportSummary(context.Background(), []string{demoName}, &buf, sdefault, opts); err != nil {
+			t.Fatal(err)
+		}
+		var header []string
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if strings.HasPrefix(line, "i ") {
+				header = strings.Fields(line)
+				break
+			}
+		}
+		want := "i UpdatedAt name updated pushed stars forks open_issues"
+		if subscribers {
+			want = "i UpdatedAt name updated pushed stars subscribers forks open_issues"
+		}
+		if got := strings.Join(header, " "); got != want {
+			t.Errorf("subscribers:%t metrics header:%q want %q", subscribers, got, want)
+		}
+	}
+}
+
+// testRepos - a page of two repo objects.
+const testRepos = `[
+{"name":"alpha","full_name":"acme/alpha","created_at":"2015-01-01T00:00:00Z","updated_at":"2026-10-01T10:00:00Z","pushed_at":"2026-09-30T10:00:00Z","watchers_count":50,"open_issues_count":12},
+{"name":"beta","full_name":"acme/beta","created_at":"2020-01-01T00:00:00Z","updated_at":"2026-09-10T10:00:00Z","pushed_at":"2026-09-02T10:00:00Z","watchers_count":3,"open_issues_count":1}
+]`
+
+func TestGetDataGzip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Errorf("Accept-Encoding:%q want gzip", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Type", "application/json")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(testRepos))
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+
+	data, _, err := getData(context.Background(), srv.URL+"/orgs/acme/repos", fetchOpts{})
+	if err != nil {
+		t.Fatalf("getData err:%v", err)
+	}
+	if len(data) != 2 || data[0].Name != "alpha" || data[1].OpenIssuesCount != 1 {
+		t.Fatalf("getData got %v", data)
+	}
+}
+
+func TestReadBodyPlain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testRepos))
+	}))
+	defer srv.Close()
+
+	data, _, err := getData(context.Background(), srv.URL, fetchOpts{})
+	if err != nil || len(data) != 2 {
+		t.Fatalf("getData got %d repos err:%v", len(data), err)
+	}
+}
+
+// repoPage - returns a json page of n generated repo objects starting at from.
+func repoPage(from, n int) []byte {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i := from; i < from+n; i++ {
+		if i > from {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id":%d,"name":"repo%d","full_name":"acme/repo%d","description":"generated repo %d",`+
+			`"created_at":"2015-01-01T00:00:00Z","updated_at":"2026-10-01T10:00:00Z","pushed_at":"2026-09-30T10:00:00Z",`+
+			`"watchers_count":%d,"open_issues_count":%d,"topics":["go","cli"],"owner":{"login":"acme","type":"Organization"}}`,
+			i, i, i, i, i%97, i%13)
+	}
+	b.WriteString("]")
+	return b.Bytes()
+}
+
+// pagedServer - returns a server of pages pages of perPage repos whose Link
+// headers have next and, when last is set, last urls.
+func pagedServer(pages, perPage int, last bool) *httptest.Server {
+	bodies := make([][]byte, pages+1)
+	for p := 1; p <= pages; p++ {
+		bodies[p] = repoPage((p-1)*perPage, perPage)
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if p < 1 || p > pages {
+			p = 1
+		}
+		var links []string
+		if p < pages {
+			links = append(links, fmt.Sprintf(`<%s/repos?page=%d&per_page=%d>; rel="next"`, srv.URL, p+1, perPage))
+			if last {
+				links = append(links, fmt.Sprintf(`<%s/repos?page=%d&per_page=%d>; rel="last"`, srv.URL, pages, perPage))
+			}
+		}
+		if links != nil {
+			w.Header().Set("Link", strings.Join(links, ", "))
+		}
+		_, _ = w.Write(bodies[p])
+	}))
+	return srv
+}
+
+func benchmarkGetData(b *testing.B, last bool) {
+	const pages, perPage = 20, 100
+	srv := pagedServer(pages, perPage, last)
+	defer srv.Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		data, _, err := getData(context.Background(), srv.URL+"/repos", fetchOpts{perPage: perPage})
+		if err != nil || len(data) != pages*perPage {
+			b.Fatalf("getData got %d repos err:%v", len(data), err)
+		}
+	}
+}
+
+// BenchmarkGetDataPresized - getData pre-sizing totData from Link rel="last".
+func BenchmarkGetDataPresized(b *testing.B) { benchmarkGetData(b, true) }
+
+// BenchmarkGetDataAppend - getData growing totData by append, no rel="last".
+func BenchmarkGetDataAppend(b *testing.B) { benchmarkGetData(b, false) }
+
+// largePage - a large page fixture for the unmarshal benchmarks.
+var largePage = repoPage(0, 5000)
+
+// BenchmarkUnmarshalRepos - the ReadAll+Unmarshal getData uses.
+func BenchmarkUnmarshalRepos(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(largePage)))
+	for i := 0; i < b.N; i++ {
+		body, err := ioutil.ReadAll(bytes.NewReader(largePage))
+		if err != nil {
+			b.Fatal(err)
+		}
+		var data []dataStruct
+		if err = unmarshalRepos(body, &data); err != nil || len(data) != 5000 {
+			b.Fatalf("got %d repos err:%v", len(data), err)
+		}
+	}
+}
+
+// BenchmarkDecodeRepos - the json.Decoder streaming alternative, element by
+// element, for comparison with BenchmarkUnmarshalRepos.
+func BenchmarkDecodeRepos(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(largePage)))
+	for i := 0; i < b.N; i++ {
+		dec := json.NewDecoder(bytes.NewReader(largePage))
+		if _, err := dec.Token(); err != nil { // opening [
+			b.Fatal(err)
+		}
+		var data []dataStruct
+		for dec.More() {
+			var d dataStruct
+			if err := dec.Decode(&d); err != nil {
+				b.Fatal(err)
+			}
+			data = append(data, d)
+		}
+		if len(data) != 5000 {
+			b.Fatalf("got %d repos", len(data))
+		}
+	}
+}
+
+func TestRateLimit403(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	for _, tc := range []struct {
+		name       string
+		headers    map[string]string
+		body       string
+		secondary  bool
+		retryAfter time.Duration
+	}{
+		{"primary", map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset, 10)},
+			`{"message":"API rate limit exceeded for 10.0.0.1."}`, false, 0},
+		{"secondary", map[string]string{"Retry-After": "60", "X-RateLimit-Remaining": "4000"},
+			`{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`, true, time.Minute},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tc.headers {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			_, _, err := getData(context.Background(), srv.URL, fetchOpts{})
+			var rle *rateLimitError
+			if !errors.As(err, &rle) {
+				t.Fatalf("err:%v want a rateLimitError", err)
+			}
+			if rle.secondary != tc.secondary || rle.retryAfter != tc.retryAfter {
+				t.Errorf("secondary:%t retryAfter:%v want %t %v", rle.secondary, rle.retryAfter, tc.secondary, tc.retryAfter)
+			}
+			if !tc.secondary && rle.reset.Unix() != reset {
+				t.Errorf("reset:%v want %v", rle.reset, time.Unix(reset, 0))
+			}
+		})
+	}
+}
+
+func TestParseLinksMalformed(t *testing.T) {
+	for _, tc := range []struct {
+		link  string
+		links map[string]string
+		bad   int
+	}{
+		{"", map[string]string{}, 0},
+		{"garbage", map[string]string{}, 1},
+		{`<>; rel="next", ;;, <https://x/?page=2>`, map[string]string{"next": ""}, 2},
+		{`<https://x/?page=2>; rel="next", nonsense; rel=last`, map[string]string{"next": "https://x/?page=2"}, 1},
+	} {
+		links, bad := parseLinks(tc.link)
+		if bad != tc.bad {
+			t.Errorf("parseLinks(%q) bad:%d want %d", tc.link, bad, tc.bad)
+		}
+		for rel, u := range tc.links {
+			if got, ok := links[rel]; !ok || got != u {
+				t.Errorf("parseLinks(%q)[%q]:%q want %q", tc.link, rel, links[rel], u)
+			}
+		}
+	}
+}
+
+func TestPaginateGarbageLink(t *testing.T) {
+	for _, link := range []string{
+		"garbage<<>>;;;rel",
+		`<http://[::1:bad>; rel="next"`,
+		`<?page=1>; rel="next"`, // points back at the first page
+		`<%s?page=1>; rel="next"`,
+	} {
+		requests := 0
+		var srv *httptest.Server
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if strings.Contains(link, "%s") {
+				w.Header().Set("Link", fmt.Sprintf(link, srv.URL))
+			} else {
+				w.Header().Set("Link", link)
+			}
+			_, _ = w.Write([]byte(testRepos))
+		}))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		data, _, err := getData(ctx, srv.URL, fetchOpts{})
+		cancel()
+		srv.Close()
+		if err != nil || len(data) != 2 || requests != 1 {
+			t.Errorf("Link %q: got %d repos in %d requests err:%v want 2 in 1", link, len(data), requests, err)
+		}
+	}
+}
+
+// missingPushedPage - a page of a repo object lacking pushed_at.
+const missingPushedPage = `[{"name":"alpha","full_name":"acme/alpha","created_at":"2015-01-01T00:00:00Z","updated_at":"2026-10-01T10:00:00Z"}]`
+
+func TestMissingPushedAt(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(missingPushedPage))
+	}))
+	defer srv.Close()
+
+	data, _, err := getData(context.Background(), srv.URL, fetchOpts{})
+	if err != nil || len(data) != 1 || !data[0].PushedAt.IsZero() {
+		t.Fatalf("lenient getData got %v err:%v want one repo with a zero PushedAt", data, err)
+	}
+	_, _, err = getData(context.Background(), srv.URL, fetchOpts{strict: true})
+	if err == nil || !strings.Contains(err.Error(), `missing field "pushed_at"`) {
+		t.Fatalf("strict getData err:%v want missing pushed_at", err)
+	}
+}
+
+func TestPrettyTableOptions(t *testing.T) {
+	opts := demoOpts(outputPrettyTable)
+	opts.span, opts.ratio, opts.annotate, opts.maxNameWidth = true, true, true, 6
+	var buf bytes.Buffer
+	if err := gitHubReposReportSummary(context.Background(), []string{demoName}, &buf, sdefault, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"│ name   │ span  │ ratio │ openIssues ", "│ web... │ 2153d │ 1.56  │ 14 (+113% vs avg) │"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("prettytable lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestFutureAges(t *testing.T) {
+	now := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	future, past := now.Add(time.Hour), now.Add(-time.Hour)
+	if got := ageOf(future, now); got != 0 {
+		t.Errorf("ageOf future:%v want 0", got)
+	}
+	if got := ageOf(past, now); got != time.Hour {
+		t.Errorf("ageOf past:%v want 1h", got)
+	}
+	data := []dataStruct{{Name: "skewed", PushedAt: future, UpdatedAt: past}}
+	setAges(data, now)
+	if p, u := *data[0].PushedAgeSeconds, *data[0].UpdatedAgeSeconds; p != 0 || u != 3600 {
+		t.Errorf("setAges pushed:%d updated:%d want 0 3600", p, u)
+	}
+}
+
+func TestFieldTimes(t *testing.T) {
+	loc := time.FixedZone("X", 2*3600)
+	opts := reportOpts{loc: loc, timePrecision: "day"}
+	d := dataStruct{UpdatedAt: time.Date(2026, 10, 1, 23, 30, 0, 0, time.UTC)}
+	if got, want := extraFields["updated"](d, opts), "2026-10-02 00:00:00 +0200 X"; got != want {
+		t.Errorf("updated field:%s want %s", got, want)
+	}
+	if got := extraFields["pushed"](d, opts); got != noValue {
+		t.Errorf("null pushed field:%s want %s", got, noValue)
+	}
+}
+
+func TestInvalidOutput(t *testing.T) {
+	_, err := parseOutputs("text,bogus")
+	if err == nil || !strings.Contains(err.Error(), `"bogus"`) {
+		t.Fatalf("parseOutputs err:%v want unknown bogus", err)
+	}
+	for _, m := range outputModeNames() {
+		if !strings.Contains(err.Error(), m) {
+			t.Errorf("parseOutputs err:%v lacks valid mode %s", err, m)
+		}
+	}
+	if _, err := parseOutputs("json,json"); err == nil || !strings.Contains(err.Error(), "repeated") {
+		t.Errorf("parseOutputs repeated err:%v", err)
+	}
+	err = gitHubReposReportSummary(context.Background(), []string{demoName}, ioutil.Discard, sdefault, demoOpts("bogus"))
+	if err == nil || !strings.Contains(err.Error(), `unknown output mode "bogus"`) {
+		t.Errorf("writeReport of output bogus err:%v want unknown output mode", err)
+	}
+}
+
+func TestHistogramWidth(t *testing.T) {
+	for _, tc := range []struct {
+		columns   string
+		histWidth int
+		want      int
+	}{
+		{"40", 0, 40},
+		{"", 0, 80},
+		{"40", 100, 100},
+	} {
+		t.Setenv("COLUMNS", tc.columns)
+		opts := demoOpts(outputHistogram)
+		opts.noHeader, opts.histWidth = true, tc.histWidth
+		var buf bytes.Buffer
+		if err := gitHubReposReportSummary(context.Background(), []string{demoName}, &buf, sdefault, opts); err != nil {
+			t.Fatal(err)
+		}
+		longest := 0
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			if !strings.Contains(line, " | ") {
+				continue
+			}
+			if n := len(line); n > longest {
+				longest = n
+			}
+		}
+		if longest != tc.want {
+			t.Errorf("COLUMNS:%q histWidth:%d longest bar line:%d want %d:\n%s", tc.columns, tc.histWidth, longest, tc.want, buf.String())
+		}
+	}
+}
+
+func TestReleasesCounted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	uncounted := dataStruct{Name: "a", URL: srv.URL + "/repos/acme/a"}
+	if got := extraFields["releases"](uncounted, reportOpts{}); got != noValue {
+		t.Errorf("uncounted releases field:%s want %s", got, noValue)
+	}
+	b, _ := json.Marshal(uncounted)
+	if strings.Contains(string(b), `"releases"`) {
+		t.Errorf("uncounted json has releases: %s", b)
+	}
+	data := []dataStruct{uncounted}
+	if err := getReleases(context.Background(), data, fetchOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := extraFields["releases"](data[0], reportOpts{}); got != "0" {
+		t.Errorf("counted releases field:%s want 0", got)
+	}
+	b, _ = json.Marshal(data[0])
+	if !strings.Contains(string(b), `"releases":0`) {
+		t.Errorf("counted zero json lacks releases:0: %s", b)
+	}
+}
+
+func TestParseBuckets(t *testing.T) {
+	for _, tc := range []struct {
+		list string
+		min  int
+		want string // the edges, "" for an error
+	}{
+		{"0,5,20", 0, "[0 5 20]"},
+		{"0,5,20", 1, ""},
+		{"30, 90,365", 1, "[30 90 365]"},
+		{"5,5", 0, ""},
+		{"9,3", 0, ""},
+		{"-1", 0, ""},
+		{"x", 0, ""},
+		{"", 0, ""},
+	} {
+		edges, err := parseBuckets(tc.list, tc.min)
+		if got := fmt.Sprint(edges); (err == nil) != (tc.want != "") || (err == nil && got != tc.want) {
+			t.Errorf("parseBuckets(%q, %d):%s err:%v want %q", tc.list, tc.min, got, err, tc.want)
+		}
+	}
+}
+
+func TestPageWait(t *testing.T) {
+	if err := pageWait(context.Background(), fetchOpts{}); err != nil {
+		t.Errorf("pageWait no delay err:%v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := pageWait(ctx, fetchOpts{pageDelay: time.Hour}); !errors.Is(err, context.Canceled) || time.Since(start) > time.Second {
+		t.Errorf("pageWait canceled err:%v after %v want context.Canceled at once", err, time.Since(start))
+	}
+}
+
+func TestRawDumpDemo(t *testing.T) {
+	var buf bytes.Buffer
+	if err := rawDump(context.Background(), []string{demoName}, &buf, fetchOpts{demo: true}); err != nil {
+		t.Fatalf("rawDump demo err:%v", err)
+	}
+	var got []dataStruct
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("rawDump demo wrote invalid json err:%v", err)
+	}
+	want, _ := getDemoData()
+	if len(got) != len(want) || got[0].FullName != want[0].FullName {
+		t.Errorf("rawDump demo got %d repos want %d", len(got), len(want))
+	}
+}
+
+func TestSinceLastRunFiltered(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "state.json")
+	run := func(exclude []string, sample int) jsonReport {
+		opts := demoOpts(outputJSON)
+		opts.sinceLastRun, opts.exclude, opts.sample, opts.seed = state, exclude, sample, 1
+		var buf bytes.Buffer
+		if err := gitHubReposReportSummary(context.Background(), []string{demoName}, &buf, sdefault, opts); err != nil {
+			t.Fatal(err)
+		}
+		var r jsonReport
+		if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	run([]string{"ghrepo"}, 3)
+	if r := run(nil, 0); len(r.Summary.ChangedSinceLast) != 0 {
+		t.Errorf("unfiltered run after a filtered one changed:%v want none, state is of everything fetched", r.Summary.ChangedSinceLast)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	opts := reportOpts{output: outputJSON, exclude: []string{"old"}, skipMirrors: true, top: 5,
+		fetch: fetchOpts{perPage: 100, pageDelay: time.Second}}
+	opts.moreOutputs = []reportOutput{{output: outputCSV}}
+	ro := runOpts{outfile: "r.{ext}", token: true, tokenfile: "tok", timeout: time.Minute,
+		maxIdleConns: 4, keepAlive: -1, maxRedirects: 3}
+	var buf bytes.Buffer
+	explain(&buf, []string{"https://api.github.com/orgs/acme/repos"}, sbyPushedAt, opts, ro)
+	for _, want := range []string{
+		"explain: urls: https://api.github.com/orgs/acme/repos\n",
+		"explain: filters: exclude:old skipmirrors top:5\n",
+		"explain: output: json,csv to outfile:r.{ext}\n",
+		"explain: auth token: yes from -tokenfile tok\n",
+		"explain: timeout: 1m0s\n",
+		"explain: connections: maxidleconns:4 keepalive:-1ns maxredirects:3 noredirect:false\n",
+		"explain: page size: 100 startpage:0 pagedelay:1s\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("explain lacks %q:\n%s", want, buf.String())
+		}
+	}
+	buf.Reset()
+	explain(&buf, []string{demoName}, sdefault, reportOpts{fetch: fetchOpts{demo: true}}, runOpts{posturl: "http://x"})
+	for _, want := range []string{"explain: demo: embedded dataset", "explain: filters: none\n",
+		"explain: output: text to posturl:http://x\n", "explain: auth token: no\n", "explain: timeout: none\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("demo explain lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestAppendCSV(t *testing.T) {
+	now := time.Now()
+	for output, want := range map[string]bool{outputText: true, outputCounts: true, outputYAML: true,
+		outputCSV: false, outputTSCSV: false, outputJSON: false, outputNDJSON: false, outputNames: false} {
+		if got := appendSeparator(output, now) != ""; got != want {
+			t.Errorf("appendSeparator(%s) written:%t want %t", output, got, want)
+		}
+	}
+
+	dir := t.TempDir()
+	empty, full := filepath.Join(dir, "empty.csv"), filepath.Join(dir, "full.csv")
+	if err := ioutil.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(full, []byte("i,UpdatedAt,name\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		output, path string
+		want         bool
+	}{
+		{outputCSV, full, true},
+		{outputTSCSV, full, true},
+		{outputCSV, empty, false},
+		{outputCSV, filepath.Join(dir, "none.csv"), false},
+		{outputText, full, false},
+	} {
+		if got := appendsRows(tc.output, tc.path); got != tc.want {
+			t.Errorf("appendsRows(%s, %s):%t want %t", tc.output, filepath.Base(tc.path), got, tc.want)
+		}
+	}
+
+	opts := demoOpts(outputCSV)
+	opts.appendRows = true
+	var buf bytes.Buffer
+	if err := gitHubReposReportSummary(context.Background(), []string{demoName}, &buf, sdefault, opts); err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(buf.String(), "i,") || !strings.HasPrefix(buf.String(), "0,") {
+		t.Errorf("appended csv rows start:%q want no header row", strings.SplitN(buf.String(), "\n", 2)[0])
+	}
+}
+
+func TestSingleRepoObject(t *testing.T) {
+	const repo = `{"name":"alpha","full_name":"acme/alpha","created_at":"2015-01-01T00:00:00Z","updated_at":"2026-10-01T10:00:00Z","pushed_at":"2026-10-01T09:00:00Z"}`
+	body := repo
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	for _, strict := range []bool{false, true} {
+		data, _, err := getData(context.Background(), srv.URL, fetchOpts{strict: strict})
+		if err != nil || len(data) != 1 || data[0].FullName != "acme/alpha" {
+			t.Errorf("strict:%t getData got %v err:%v want acme/alpha", strict, data, err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := rawDump(context.Background(), []string{srv.URL}, &buf, fetchOpts{}); err != nil {
+		t.Fatalf("rawDump err:%v", err)
+	}
+	var objs []dataStruct
+	if err := json.Unmarshal(buf.Bytes(), &objs); err != nil || len(objs) != 1 || objs[0].FullName != "acme/alpha" {
+		t.Errorf("rawDump wrote %s err:%v want an array of acme/alpha", buf.String(), err)
+	}
+
+	body = `{"login":"acme"}`
+	if _, _, err := getData(context.Background(), srv.URL, fetchOpts{strict: true}); !errors.Is(err, errNotRepoList) {
+		t.Errorf("strict getData of a non repo object err:%v want %v", err, errNotRepoList)
+	}
+	if err := rawDump(context.Background(), []string{srv.URL}, ioutil.Discard, fetchOpts{}); !errors.Is(err, errNotRepoList) {
+		t.Errorf("rawDump of a non repo object err:%v want %v", err, errNotRepoList)
+	}
+}
+
+func TestDataStructString(t *testing.T) {
+	const empty = `[Name:- FullName:- Description:- CreatedAt:- UpdatedAt:- PushedAt:- WatchersCount:0 SubscribersCount:0 OpenIssuesCount:0 Disabled:false MirrorURL:- Language:- License:- Topics:-]`
+	if got := (dataStruct{}).String(); got != empty {
+		t.Errorf("empty dataStruct String():\n%s\nwant:\n%s", got, empty)
+	}
+	d := dataStruct{Name: "alpha", Description: "the alpha", Language: "Go", License: &licenseStruct{SPDXID: "MIT"}, Topics: []string{"go", "cli"}}
+	for _, want := range []string{"Name:alpha ", "Description:the alpha ", "Language:Go ", "License:MIT ", "Topics:go,cli]"} {
+		if got := d.String(); !strings.Contains(got, want) {
+			t.Errorf("String():%s lacks %s", got, want)
+		}
+	}
+}
+
+func TestFlagConflicts(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		fl      flagsStruct
+		outputs []string
+		err     string // substring of the error, "" for none
+	}{
+		{"none", flagsStruct{}, []string{outputText}, ""},
+		{"one sort", flagsStruct{bypushedat: true, ascending: true}, []string{outputText}, ""},
+		{"two sorts", flagsStruct{bypushedat: true, bysubscribers: true}, []string{outputText}, "conflicting sort flags -bypushedat -bysubscribers"},
+		{"sort and sortexpr", flagsStruct{byissueratio: true, sortexpr: "{{.ForksCount}}"}, []string{outputText}, "conflicting sort flags -byissueratio -sortexpr"},
+		{"xlsx no outfile", flagsStruct{}, []string{outputXLSX}, "-output xlsx needs an -outfile"},
+		{"xlsx outfile", flagsStruct{outfile: "r.xlsx"}, []string{outputXLSX}, ""},
+		{"appid alone", flagsStruct{appid: "1"}, []string{outputText}, "go together"},
+		{"app", flagsStruct{appid: "1", appkey: "k.pem", installationid: "2"}, []string{outputText}, ""},
+		{"append stdout", flagsStruct{append: true}, []string{outputCounts}, "-append needs an -outfile"},
+		{"outputs no ext", flagsStruct{outfile: "r.txt"}, []string{outputText, outputJSON}, "{ext}"},
+		{"outputs posturl", flagsStruct{outfile: "r.{ext}", posturl: "http://x"}, []string{outputJSON, outputCSV}, "can't be used with -posturl"},
+		{"compare rawdump", flagsStruct{compare: true, rawdump: true}, []string{outputText}, "-compare can't be used"},
+		{"redact rawdump", flagsStruct{redact: true, rawdump: true}, []string{outputText}, "-redact can't apply"},
+		{"demo rawdump", flagsStruct{demo: true, rawdump: true}, []string{outputText}, ""},
+		{"flatten text", flagsStruct{flatten: true}, []string{outputText}, "-flatten requires"},
+		{"posturl text", flagsStruct{posturl: "http://x"}, []string{outputText}, "-posturl requires -output json"},
+		{"cacert insecure", flagsStruct{cacert: "ca.pem", insecure: true}, []string{outputText}, "-cacert and -insecure"},
+	} {
+		_, err := sortTypeOf(tc.fl)
+		if err == nil {
+			err = checkConflicts(tc.fl, tc.outputs)
+		}
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: err:%v want none", tc.name, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: err:%v want %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+// BenchmarkAPIClient - overlapping apiGets, 8 per cpu, through clients with
+// the transport's default idle limit of 2, the default -maxidleconns and
+// keep-alives disabled by a negative -keepalive.
+func BenchmarkAPIClient(b *testing.B) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testRepos))
+	}))
+	defer srv.Close()
+	defer func(c *http.Client) { apiClient = c }(apiClient)
+	for _, bc := range []struct {
+		name      string
+		maxIdle   int
+		keepAlive time.Duration
+	}{
+		{"idle2", 2, defKeepAlive},
+		{"idle10", defMaxIdlePerHost, defKeepAlive},
+		{"nokeepalive", defMaxIdlePerHost, -1},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			apiClient = newAPIClient(bc.maxIdle, bc.keepAlive, nil)
+			b.SetParallelism(8)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, _, err := apiGet(context.Background(), srv.URL); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			apiClient.CloseIdleConnections()
+		})
+	}
+}
+
+func TestRedactPrivate(t *testing.T) {
+	data := []dataStruct{
+		{ID: 1, Name: "public", FullName: "acme/public", HTMLURL: "https://github.com/acme/public"},
+		{ID: 2, Name: "SecretProj", FullName: "acme/SecretProj", Private: true, Description: "the secretproj service",
+			URL: "https://api.github.com/repos/acme/SecretProj", HTMLURL: "https://github.com/acme/SecretProj",
+			MirrorURL: "https://git.example.com/SecretProj", Topics: []string{"go", "secretproj-tools"},
+			WatchersCount: 7, OpenIssuesCount: 3},
+	}
+	redactPrivate(data)
+	if data[0].Name != "public" || data[0].HTMLURL == "" {
+		t.Errorf("public repo changed: %v", data[0])
+	}
+	d := data[1]
+	if d.Name != "private-repo-1" || d.FullName != "acme/private-repo-1" || d.WatchersCount != 7 || d.OpenIssuesCount != 3 {
+		t.Errorf("redacted repo name:%s full_name:%s watchers:%d open_issues:%d", d.Name, d.FullName, d.WatchersCount, d.OpenIssuesCount)
+	}
+	body, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(strings.ToLower(string(body)), "secretproj") {
+		t.Errorf("redacted repo still names the original: %s", body)
+	}
+	for _, v := range checkPolicy(policyStruct{"open_issues": {Max: new(float64)}}, data, false) {
+		if strings.Contains(strings.ToLower(v.IssuesURL), "secretproj") {
+			t.Errorf("policy violation issues_url %q names the original", v.IssuesURL)
+		}
+	}
+}
+
+// BenchmarkBufSize - an ndjson report of 5000 repos written to a file
+// unbuffered and through bufio writers of several -bufsize.
+func BenchmarkBufSize(b *testing.B) {
+	var data []dataStruct
+	if err := json.Unmarshal(largePage, &data); err != nil {
+		b.Fatal(err)
+	}
+	bdata := sorterFor(sdefault, ghStruct{data: data})
+	opts := demoOpts(outputNDJSON)
+	opts.silent = true // no summary line on stderr
+	f, err := ioutil.TempFile(b.TempDir(), "report")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	for _, size := range []int{0, 4096, defBufSize, 1 << 20} {
+		b.Run(fmt.Sprintf("bufsize%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := f.Seek(0, io.SeekStart); err != nil {
+					b.Fatal(err)
+				}
+				var w io.Writer = f
+				var bw *bufio.Writer
+				if size > 0 {
+					bw = bufio.NewWriterSize(f, size)
+					w = bw
+				}
+				if err := writeReport(w, defReportName, "bench", bdata, data, summaryStruct{}, nil, nil, opts); err != nil {
+					b.Fatal(err)
+				}
+				if bw != nil {
+					if err := bw.Flush(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRepoCountWarnings(t *testing.T) {
+	repos := func(n int) []dataStruct {
+		data := make([]dataStruct, n)
+		for i := range data {
+			data[i].Owner.Login = "acme"
+		}
+		return data
+	}
+	for _, tc := range []struct {
+		name    string
+		org     orgInfo
+		fetched int
+		warn    bool
+	}{
+		{"match", orgInfo{Login: "acme", PublicRepos: 4, allRepos: true}, 4, false},
+		{"small org off by one", orgInfo{Login: "acme", PublicRepos: 4, allRepos: true}, 3, false},
+		{"small org off by two", orgInfo{Login: "acme", PublicRepos: 4, allRepos: true}, 2, true},
+		{"within 5%", orgInfo{Login: "acme", PublicRepos: 200, allRepos: true}, 191, false},
+		{"past 5%", orgInfo{Login: "acme", PublicRepos: 200, allRepos: true}, 189, true},
+		{"team", orgInfo{Login: "acme", PublicRepos: 200}, 3, false},
+	} {
+		if got := repoCountWarnings([]orgInfo{tc.org}, repos(tc.fetched)); (len(got) > 0) != tc.warn {
+			t.Errorf("%s: warnings %q want warning:%t", tc.name, got, tc.warn)
+		}
+	}
+	for urlname, want := range map[string]bool{
+		"https://api.github.com/orgs/acme/repos":            true,
+		"https://ghe.example.com/api/v3/orgs/acme/repos":    true,
+		"https://api.github.com/orgs/acme/teams/core/repos": false,
+		"https://api.github.com/users/acme/repos":           false,
+	} {
+		if got := orgReposURL(urlname); got != want {
+			t.Errorf("orgReposURL(%s):%t want %t", urlname, got, want)
+		}
+	}
+}
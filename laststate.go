@@ -0,0 +1,85 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+)
+
+// runState - the -sincelastrun file contents, the pushed_at of each repo of
+// the previous run keyed by repo id.
+type runState struct {
+	Time     time.Time            `json:"time"`
+	PushedAt map[string]time.Time `json:"pushed_at"`
+}
+
+// loadRunState - returns the run state in file, nil when there's no file yet.
+func loadRunState(file string) (*runState, error) {
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st runState
+	if err = json.Unmarshal(b, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// newRunState - returns the run state of data as of now.
+func newRunState(data []dataStruct) runState {
+	st := runState{Time: time.Now().UTC().Truncate(time.Second), PushedAt: make(map[string]time.Time)}
+	for _, v := range data {
+		if v.ID != 0 {
+			st.PushedAt[strconv.FormatInt(v.ID, 10)] = v.PushedAt
+		}
+	}
+	return st
+}
+
+// saveRunState - replaces file with st.
+func saveRunState(file string, st runState) error {
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	af, err := createAtomic(file)
+	if err != nil {
+		return err
+	}
+	if _, err = af.Write(append(b, '\n')); err != nil {
+		af.abort()
+		return err
+	}
+	return af.commit()
+}
+
+// changedSince - returns the repos of data pushed since the run of st,
+// that is whose pushed_at advanced or that weren't in that run.
+func changedSince(st *runState, data []dataStruct, fullname bool) []changedRepo {
+	var out []changedRepo
+	for _, v := range data {
+		prev, ok := st.PushedAt[strconv.FormatInt(v.ID, 10)]
+		if ok && !v.PushedAt.After(prev) {
+			continue
+		}
+		out = append(out, changedRepo{Name: v.displayName(fullname), PushedAt: v.PushedAt, New: !ok})
+	}
+	return out
+}
+
+// changedRepo - a repo pushed since the last -sincelastrun run.
+type changedRepo struct {
+	Name     string    `json:"name"`
+	PushedAt time.Time `json:"pushed_at"`
+	New      bool      `json:"new,omitempty"` // not in the last run
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// atomicFile - an io.Writer for -outfile that writes a temp file in the same
+// directory and renames it over the target on commit, so readers of the
+// target never see partial output.
+type atomicFile struct {
+	path string
+	f    *os.File
+	w    *bufio.Writer
+	buf  *bytes.Buffer // output held for appending, nil when replacing
+	sep  string        // written before buf when appending
+}
+
+// createAtomic - returns an atomicFile for path.
+func createAtomic(path string) (*atomicFile, error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{path: path, f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// createAppend - returns an atomicFile that holds the output and on commit
+// appends sep then the output to path, see -append.
+func createAppend(path, sep string) *atomicFile {
+	buf := new(bytes.Buffer)
+	return &atomicFile{path: path, w: bufio.NewWriter(buf), buf: buf, sep: sep}
+}
+
+func (a *atomicFile) Write(p []byte) (int, error) {
+	return a.w.Write(p)
+}
+
+// commit - flushes and renames the temp file to the target path, or when
+// appending writes the held output to its end in one write.
+func (a *atomicFile) commit() error {
+	if err := a.w.Flush(); err != nil {
+		a.abort()
+		return err
+	}
+	if a.buf != nil {
+		f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err = f.Write(append([]byte(a.sep), a.buf.Bytes()...)); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	if err := a.f.Sync(); err != nil {
+		a.abort()
+		return err
+	}
+	if err := a.f.Close(); err != nil {
+		_ = os.Remove(a.f.Name())
+		return err
+	}
+	if err := os.Chmod(a.f.Name(), 0644); err != nil {
+		_ = os.Remove(a.f.Name())
+		return err
+	}
+	if err := os.Rename(a.f.Name(), a.path); err != nil {
+		_ = os.Remove(a.f.Name())
+		return err
+	}
+	return nil
+}
+
+// abort - discards the temp file leaving the target untouched.
+func (a *atomicFile) abort() {
+	if a.buf != nil {
+		return
+	}
+	_ = a.f.Close()
+	_ = os.Remove(a.f.Name())
+}
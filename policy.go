@@ -0,0 +1,143 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+)
+
+// repoMetrics - numeric per repo metrics by name that policies can limit.
+var repoMetrics = map[string]func(d dataStruct) float64{
+	"open_issues":     func(d dataStruct) float64 { return float64(d.OpenIssuesCount) },
+	"watchers":        func(d dataStruct) float64 { return float64(d.WatchersCount) },
+	"subscribers":     func(d dataStruct) float64 { return float64(d.SubscribersCount) },
+	"issue_ratio":     func(d dataStruct) float64 { return d.issueRatio() },
+	"span_days":       func(d dataStruct) float64 { return days(d.span()) },
+	"days_since_push": func(d dataStruct) float64 { return days(ageOf(d.PushedAt, time.Now())) },
+}
+
+// repoMetricNames - returns the sorted names of repoMetrics.
+func repoMetricNames() []string {
+	var names []string
+	for k := range repoMetrics {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// policyLimit - bounds a metric must stay within, nil bounds are not checked.
+type policyLimit struct {
+	Max *float64 `json:"max"`
+	Min *float64 `json:"min"`
+}
+
+// policyStruct - limits by repoMetrics name, e.g. {"open_issues":{"max":50}}.
+type policyStruct map[string]policyLimit
+
+// loadPolicy - reads and validates the policy json file fname.
+func loadPolicy(fname string) (policyStruct, error) {
+	body, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var policy policyStruct
+	if err = json.Unmarshal(body, &policy); err != nil {
+		return nil, fmt.Errorf("%s: %v", fname, err)
+	}
+	for metric, limit := range policy {
+		if _, ok := repoMetrics[metric]; !ok {
+			return nil, fmt.Errorf("%s: unknown metric %q valid metrics:%s",
+				fname, metric, strings.Join(repoMetricNames(), ","))
+		}
+		if limit.Max == nil && limit.Min == nil {
+			return nil, fmt.Errorf("%s: metric %q has neither max nor min", fname, metric)
+		}
+	}
+	return policy, nil
+}
+
+// policyViolation - a repo metric outside its policy limit.
+type policyViolation struct {
+	Repo   string  `json:"repo"`
+	Metric string  `json:"metric"`
+	Value  float64 `json:"value"`
+	Bound  string  `json:"bound"` // max or min
+	Limit  float64 `json:"limit"`
+	// Message describes violations of non metric rules e.g. -requirelicense,
+	// which leave the metric fields empty.
+	Message string `json:"message,omitempty"`
+	// IssuesURL links open_issues violations to the repo's issues page.
+	IssuesURL string `json:"issues_url,omitempty"`
+}
+
+func (v policyViolation) String() string {
+	if v.Message != "" {
+		return fmt.Sprintf("policy violation repo:%s %s", v.Repo, v.Message)
+	}
+	msg := fmt.Sprintf("policy violation repo:%s metric:%s value:%g %s:%g",
+		v.Repo, v.Metric, v.Value, v.Bound, v.Limit)
+	if v.IssuesURL != "" {
+		msg += " " + v.IssuesURL
+	}
+	return msg
+}
+
+// policyError - returned when repos violate the policy.
+type policyError struct {
+	violations []policyViolation
+}
+
+func (e *policyError) Error() string {
+	return fmt.Sprintf("%d policy violations", len(e.violations))
+}
+
+// checkPolicy - returns the violations of policy by data in repo then metric
+// name order, repos are named by displayName(fullname).
+func checkPolicy(policy policyStruct, data []dataStruct, fullname bool) []policyViolation {
+	var metrics []string
+	for metric := range policy {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+
+	var violations []policyViolation
+	for _, d := range data {
+		for _, metric := range metrics {
+			limit := policy[metric]
+			value := repoMetrics[metric](d)
+			issuesURL := ""
+			if metric == "open_issues" && d.HTMLURL != "" {
+				issuesURL = d.HTMLURL + "/issues"
+			}
+			if limit.Max != nil && value > *limit.Max {
+				violations = append(violations, policyViolation{Repo: d.displayName(fullname), Metric: metric,
+					Value: value, Bound: "max", Limit: *limit.Max, IssuesURL: issuesURL})
+			}
+			if limit.Min != nil && value < *limit.Min {
+				violations = append(violations, policyViolation{Repo: d.displayName(fullname), Metric: metric,
+					Value: value, Bound: "min", Limit: *limit.Min, IssuesURL: issuesURL})
+			}
+		}
+	}
+	return violations
+}
+
+// checkLicenses - returns a violation for each public repo of data without a
+// license, private repos are left to their owners.
+func checkLicenses(data []dataStruct, fullname bool) []policyViolation {
+	var violations []policyViolation
+	for _, d := range data {
+		if !d.Private && d.License == nil {
+			violations = append(violations, policyViolation{Repo: d.displayName(fullname), Message: "has no license"})
+		}
+	}
+	return violations
+}
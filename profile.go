@@ -0,0 +1,62 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// stopProfiles - stops the -cpuprofile and writes the -memprofile started
+// by startProfiles, main calls it before exiting. Does nothing until then
+// and after the first call.
+var stopProfiles = func() {}
+
+// startProfiles - starts a cpu profile written to cpu and arranges for a
+// heap profile to be written to mem by stopProfiles, "" skips either.
+func startProfiles(cpu, mem string) error {
+	var cpuf *os.File
+	if cpu != "" {
+		var err error
+		if cpuf, err = os.Create(cpu); err != nil {
+			return err
+		}
+		if err = pprof.StartCPUProfile(cpuf); err != nil {
+			_ = cpuf.Close()
+			return err
+		}
+	}
+	stopProfiles = func() {
+		stopProfiles = func() {}
+		if cpuf != nil {
+			pprof.StopCPUProfile()
+			if err := cpuf.Close(); err != nil {
+				log.Printf("-cpuprofile err:%v\n", err)
+			}
+		}
+		if mem != "" {
+			if err := writeHeapProfile(mem); err != nil {
+				log.Printf("-memprofile err:%v\n", err)
+			}
+		}
+	}
+	return nil
+}
+
+// writeHeapProfile - writes a heap profile, as of a fresh gc, to file.
+func writeHeapProfile(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err = pprof.WriteHeapProfile(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// promEscaper - escapes label values per the prometheus text format.
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabels - returns the {k="v",...} label set of the name value pairs in kv.
+func promLabels(kv ...string) string {
+	var b strings.Builder
+	b.WriteString("{")
+	for i := 0; i+1 < len(kv); i += 2 {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `%s="%s"`, kv[i], promEscaper.Replace(kv[i+1]))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// promMetric - writes the HELP and TYPE lines of a gauge metric.
+func promMetric(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// writePrometheus - writes the report as prometheus textfile format metrics,
+// totals are per owner and per repo gauges are added only when perRepo since
+// they grow with the number of repos.
+func writePrometheus(w io.Writer, sum summaryStruct, groups []groupStruct, repos []dataStruct, perRepo bool) error {
+	promMetric(w, "ghrepo_repos_total", "Number of repos reported.")
+	for _, g := range groups {
+		fmt.Fprintf(w, "ghrepo_repos_total%s %d\n", promLabels("owner", g.Name), g.Repos)
+	}
+	promMetric(w, "ghrepo_open_issues_total", "Open issues and pull requests of the repos reported.")
+	for _, g := range groups {
+		fmt.Fprintf(w, "ghrepo_open_issues_total%s %d\n", promLabels("owner", g.Name), g.OpenIssues)
+	}
+	promMetric(w, "ghrepo_max_watchers", "Highest watchers_count of the repos reported.")
+	fmt.Fprintf(w, "ghrepo_max_watchers %d\n", sum.MaxWatchers)
+	promMetric(w, "ghrepo_disabled_repos", "Number of repos disabled by GitHub.")
+	fmt.Fprintf(w, "ghrepo_disabled_repos %d\n", sum.DisabledRepos)
+	promMetric(w, "ghrepo_mirror_repos", "Number of mirror repos.")
+	fmt.Fprintf(w, "ghrepo_mirror_repos %d\n", sum.MirrorRepos)
+
+	if !perRepo {
+		return nil
+	}
+	promMetric(w, "ghrepo_repo_open_issues", "Open issues and pull requests of a repo.")
+	for _, v := range repos {
+		fmt.Fprintf(w, "ghrepo_repo_open_issues%s %d\n", promLabels("owner", v.owner(), "repo", v.Name), v.OpenIssuesCount)
+	}
+	promMetric(w, "ghrepo_repo_watchers", "watchers_count of a repo.")
+	for _, v := range repos {
+		fmt.Fprintf(w, "ghrepo_repo_watchers%s %d\n", promLabels("owner", v.owner(), "repo", v.Name), v.WatchersCount)
+	}
+	promMetric(w, "ghrepo_repo_pushed_timestamp_seconds", "Unix time of the last push to a repo.")
+	for _, v := range repos {
+		if v.PushedAt.IsZero() {
+			continue // never pushed, null pushed_at
+		}
+		fmt.Fprintf(w, "ghrepo_repo_pushed_timestamp_seconds%s %d\n", promLabels("owner", v.owner(), "repo", v.Name), v.PushedAt.Unix())
+	}
+	return nil
+}
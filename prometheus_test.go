@@ -0,0 +1,36 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusNullPushedAt(t *testing.T) {
+	var repos []dataStruct
+	if err := json.Unmarshal([]byte(missingPushedPage), &repos); err != nil {
+		t.Fatal(err)
+	}
+	pushed := time.Date(2026, 9, 30, 10, 0, 0, 0, time.UTC)
+	repos = append(repos, dataStruct{Name: "beta", FullName: "acme/beta", PushedAt: pushed})
+	var buf bytes.Buffer
+	if err := writePrometheus(&buf, summaryStruct{}, nil, repos, true); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, `ghrepo_repo_pushed_timestamp_seconds{owner="acme",repo="alpha"}`) {
+		t.Errorf("null pushed_at repo has a pushed gauge:\n%s", out)
+	}
+	if want := `ghrepo_repo_pushed_timestamp_seconds{owner="acme",repo="beta"} 1790762400`; !strings.Contains(out, want) {
+		t.Errorf("lacks %s:\n%s", want, out)
+	}
+	if !strings.Contains(out, `ghrepo_repo_open_issues{owner="acme",repo="alpha"} 0`) {
+		t.Errorf("null pushed_at repo lost its other gauges:\n%s", out)
+	}
+}
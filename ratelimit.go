@@ -0,0 +1,144 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateGate - coordinates every api request around the primary rate limit,
+// once any response shows none remaining all requests, from whichever
+// goroutine, hold until the reset rather than each spending a request to
+// find out. Only holds when wait is set, see -ratewait.
+type rateGate struct {
+	mu      sync.Mutex
+	wait    bool
+	verbose int
+	until   time.Time // requests hold until then, zero when not limited
+	last    rateStatus
+}
+
+// rateStatus - the primary rate limit as of a response.
+type rateStatus struct {
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Reset     int64 `json:"reset"` // unix seconds
+}
+
+func (s rateStatus) String() string {
+	return fmt.Sprintf("remaining:%d/%d reset:%v", s.Remaining, s.Limit, time.Unix(s.Reset, 0).UTC())
+}
+
+// apiRate - the rateGate of apiGet, set up in main.
+var apiRate = &rateGate{}
+
+// observe - notes the limit headers of res, a remaining of 0 holds further
+// requests until the reset.
+func (g *rateGate) observe(res *http.Response) {
+	remaining, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	secs, _ := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+	limit, _ := strconv.Atoi(res.Header.Get("X-RateLimit-Limit"))
+	g.mu.Lock()
+	g.last = rateStatus{limit, remaining, secs}
+	g.mu.Unlock()
+	if remaining == 0 && secs > 0 {
+		g.hold(time.Unix(secs, 0))
+	}
+}
+
+// lastStatus - returns the rate limit of the last response that had one,
+// ok is false when none did.
+func (g *rateGate) lastStatus() (rateStatus, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.last, g.last != rateStatus{}
+}
+
+// hold - holds requests until reset, an earlier hold is only extended.
+func (g *rateGate) hold(reset time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if reset.After(g.until) {
+		g.until = reset
+	}
+}
+
+// holding - returns whether g waits for resets, so a refused request is
+// worth retrying after one.
+func (g *rateGate) holding() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.wait
+}
+
+// await - returns once requests may go ahead, at once when not limited or
+// not waiting, else after the reset or with ctx's error when it's done first.
+func (g *rateGate) await(ctx context.Context) error {
+	g.mu.Lock()
+	wait := time.Until(g.until)
+	hold := g.wait && wait > 0
+	g.mu.Unlock()
+	if !hold {
+		return nil
+	}
+	if g.verbose > 0 {
+		log.Printf("rate limit: holding requests %v until the reset\n", wait.Round(time.Second))
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// rateLimitURL - returns the /rate_limit endpoint of the api urlname is on.
+func rateLimitURL(urlname string) string {
+	return apiRoot(urlname) + "/rate_limit"
+}
+
+// apiRoot - returns the root of the api urlname is on, e.g.
+// https://ghe.example.com/api/v3 for an enterprise org url.
+func apiRoot(urlname string) string {
+	u, err := url.Parse(urlname)
+	if err != nil || u.Host == "" {
+		return apiBase
+	}
+	base := u.Path
+	for _, p := range []string{"/orgs/", "/users/", "/repos/", "/user/", "/teams/"} {
+		if i := strings.Index(base, p); i >= 0 {
+			base = base[:i]
+		}
+	}
+	u.Path, u.RawQuery = strings.TrimSuffix(base, "/"), ""
+	return u.String()
+}
+
+// getRateLimit - returns the core rate limit from the /rate_limit endpoint of
+// the api urlname is on, requests to it don't count against the limit.
+func getRateLimit(ctx context.Context, urlname string) (rateStatus, error) {
+	_, body, err := apiGet(ctx, rateLimitURL(urlname))
+	if err != nil {
+		return rateStatus{}, err
+	}
+	var rl struct {
+		Resources struct {
+			Core rateStatus `json:"core"`
+		} `json:"resources"`
+	}
+	err = apiUnmarshal(body, &rl)
+	return rl.Resources.Core, err
+}
@@ -0,0 +1,95 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRateGateWorkers - workers hitting a limited server at once all hold
+// for the limit to clear, once it's seen, rather than each being refused.
+func TestRateGateWorkers(t *testing.T) {
+	const workers, rounds = 8, 5
+	for _, tc := range []struct {
+		name   string
+		refuse func(w http.ResponseWriter, until time.Time)
+	}{
+		{"primary", func(w http.ResponseWriter, until time.Time) {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(until.Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+		}},
+		{"secondary", func(w http.ResponseWriter, until time.Time) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(g *rateGate) { apiRate = g }(apiRate)
+			apiRate = &rateGate{wait: true}
+
+			// limited for the first request's second, reset at the next whole one.
+			var mu sync.Mutex
+			var until time.Time
+			var refused, served, early int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				if until.IsZero() {
+					until = time.Now().Truncate(time.Second).Add(time.Second)
+				}
+				u := until
+				mu.Unlock()
+				if time.Now().Before(u) && atomic.LoadInt64(&served) == 0 {
+					atomic.AddInt64(&refused, 1)
+					tc.refuse(w, u)
+					return
+				}
+				if time.Now().Before(u) {
+					atomic.AddInt64(&early, 1)
+				}
+				atomic.AddInt64(&served, 1)
+				_, _ = w.Write([]byte(`[]`))
+			}))
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			var wg sync.WaitGroup
+			errs := make(chan error, workers*rounds)
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for r := 0; r < rounds; r++ {
+						if _, _, err := apiGet(ctx, srv.URL); err != nil {
+							errs <- err
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Errorf("worker err:%v", err)
+			}
+			if served != workers*rounds || refused == 0 || refused > workers {
+				t.Errorf("served:%d refused:%d want %d served and 1 to %d refused", served, refused, workers*rounds, workers)
+			}
+			if early > 0 {
+				t.Errorf("%d requests went ahead before the limit cleared", early)
+			}
+		})
+	}
+}
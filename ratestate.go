@@ -0,0 +1,54 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// rateState - the -ratestate file contents, remembering a rate limit hit so
+// the next run doesn't spend a request finding out it is still limited.
+type rateState struct {
+	Reset     time.Time `json:"reset"` // when requests may resume
+	Secondary bool      `json:"secondary"`
+}
+
+// loadRateState - returns the rate state in file, a missing file returns a
+// zero rateState.
+func loadRateState(file string) (rateState, error) {
+	var st rateState
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	err = json.Unmarshal(b, &st)
+	return st, err
+}
+
+// saveRateState - records in file when the limit of e resets, errors without
+// a known reset record nothing.
+func saveRateState(file string, e *rateLimitError) error {
+	st := rateState{Reset: e.reset, Secondary: e.secondary}
+	if e.secondary {
+		st.Reset = time.Time{}
+		if e.retryAfter > 0 {
+			st.Reset = time.Now().Add(e.retryAfter)
+		}
+	}
+	if st.Reset.IsZero() {
+		return nil
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(b, '\n'), 0644)
+}
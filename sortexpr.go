@@ -0,0 +1,109 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// sortExprFuncs - functions available to -sortexpr templates besides the
+// text/template builtins such as len.
+var sortExprFuncs = template.FuncMap{
+	"days":  func(t time.Time) float64 { return days(ageOf(t, time.Now())) },
+	"ratio": func(d dataStruct) float64 { return d.issueRatio() },
+	"lower": strings.ToLower,
+}
+
+// sortExprSample - the repo -sortexpr is checked on, its pointer fields set
+// so expressions through them e.g. {{.License.Name}} check their field names.
+func sortExprSample() dataStruct {
+	var releases int
+	var protected bool
+	var pushedAge, updatedAge int64
+	return dataStruct{License: &licenseStruct{}, Releases: &releases, Protected: &protected,
+		PushedAgeSeconds: &pushedAge, UpdatedAgeSeconds: &updatedAge}
+}
+
+// parseSortExpr - returns the -sortexpr template expr, a text/template over
+// a dataStruct e.g. {{len .Name}}, checked by executing it on sortExprSample
+// so bad field names error before any fetching.
+func parseSortExpr(expr string) (*template.Template, error) {
+	t, err := template.New("sortexpr").Funcs(sortExprFuncs).Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = execSortExpr(t, sortExprSample()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// execSortExpr - returns the trimmed result of t for d, "" when t goes
+// through a nil pointer of d e.g. .License.Name of a repo without a license.
+func execSortExpr(t *template.Template, d dataStruct) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		if strings.Contains(err.Error(), "nil pointer evaluating") {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// byExpr stuff for sort.Sort, sorting by the -sortexpr value of each repo,
+// numerically when every value is a number else as strings.
+type byExpr struct {
+	ghStruct
+	keys    []string
+	nums    []float64
+	numeric bool
+}
+
+// newByExpr - returns a byExpr for g evaluating g.expr on each repo, repos the
+// expr fails on get an empty value, which sorts as 0 when the rest are numbers.
+func newByExpr(g ghStruct) byExpr {
+	a := byExpr{ghStruct: g, keys: make([]string, len(g.data)), nums: make([]float64, len(g.data)), numeric: true}
+	for i, d := range g.data {
+		a.keys[i], _ = execSortExpr(g.expr, d)
+		if a.keys[i] == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(a.keys[i], 64)
+		if err != nil {
+			a.numeric = false
+		}
+		a.nums[i] = n
+	}
+	return a
+}
+
+func (a byExpr) Title() string         { return a.title }
+func (a byExpr) FieldName() string     { return "SortExpr" }
+func (a byExpr) Name(i int) string     { return a.data[i].displayName(a.fullname) }
+func (a byExpr) Field(i int) string    { return a.keys[i] }
+func (a byExpr) Repo(i int) dataStruct { return a.data[i] }
+func (a byExpr) Len() int              { return len(a.data) }
+func (a byExpr) Swap(i, j int) {
+	a.data[i], a.data[j] = a.data[j], a.data[i]
+	a.keys[i], a.keys[j] = a.keys[j], a.keys[i]
+	a.nums[i], a.nums[j] = a.nums[j], a.nums[i]
+}
+func (a byExpr) Less(i, j int) bool {
+	if a.numeric {
+		if a.sortasc {
+			return a.nums[i] < a.nums[j]
+		}
+		return a.nums[i] > a.nums[j]
+	}
+	if a.sortasc {
+		return a.keys[i] < a.keys[j]
+	}
+	return a.keys[i] > a.keys[j]
+}
@@ -0,0 +1,42 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSortExprNilPointer(t *testing.T) {
+	expr, err := parseSortExpr("{{.License.Name}}")
+	if err != nil {
+		t.Fatalf("parseSortExpr {{.License.Name}} err:%v", err)
+	}
+	if _, err := parseSortExpr("{{.License.Bogus}}"); err == nil {
+		t.Errorf("parseSortExpr {{.License.Bogus}} want a bad field error")
+	}
+	data := []dataStruct{
+		{Name: "none"},
+		{Name: "mit", License: &licenseStruct{Name: "MIT License"}},
+		{Name: "apache", License: &licenseStruct{Name: "Apache License 2.0"}},
+	}
+	a := newByExpr(ghStruct{data: data, sortasc: true, expr: expr})
+	sort.Sort(a)
+	var names []string
+	for i := 0; i < a.Len(); i++ {
+		names = append(names, a.Name(i)+"="+a.Field(i))
+	}
+	if got, want := strings.Join(names, ","), "none=,apache=Apache License 2.0,mit=MIT License"; got != want {
+		t.Errorf("sorted:%s want %s", got, want)
+	}
+
+	if expr, err = parseSortExpr("{{len .License.Name}}"); err != nil {
+		t.Fatal(err)
+	}
+	if a = newByExpr(ghStruct{data: data, expr: expr}); !a.numeric {
+		t.Errorf("a nil License made {{len .License.Name}} sort as strings keys:%q", a.keys)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// boxChars - the characters drawing a table's borders, in the order
+// horizontal, vertical, then the corners and junctions top left, top, top
+// right, left, middle, right, bottom left, bottom, bottom right.
+type boxChars [11]string
+
+var (
+	unicodeBox = boxChars{"─", "│", "┌", "┬", "┐", "├", "┼", "┤", "└", "┴", "┘"}
+	asciiBox   = boxChars{"-", "|", "+", "+", "+", "+", "+", "+", "+", "+", "+"}
+)
+
+// writeBoxTable - writes rows as a bordered table, rows[0] is the header
+// separated from the rest by a line, columns padded by display width.
+func writeBoxTable(w io.Writer, rows [][]string, ascii bool) error {
+	box := unicodeBox
+	if ascii {
+		box = asciiBox
+	}
+	var widths []int
+	for _, row := range rows {
+		for c, cell := range row {
+			if c == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := displayWidth(cell); n > widths[c] {
+				widths[c] = n
+			}
+		}
+	}
+	bw := bufio.NewWriter(w)
+	rule := func(left, mid, right string) {
+		bw.WriteString(left)
+		for c, n := range widths {
+			if c > 0 {
+				bw.WriteString(mid)
+			}
+			bw.WriteString(strings.Repeat(box[0], n+2))
+		}
+		bw.WriteString(right + "\n")
+	}
+	rule(box[2], box[3], box[4])
+	for r, row := range rows {
+		for c, n := range widths {
+			cell := ""
+			if c < len(row) {
+				cell = row[c]
+			}
+			bw.WriteString(box[1] + " " + cell + strings.Repeat(" ", n-displayWidth(cell)) + " ")
+		}
+		bw.WriteString(box[1] + "\n")
+		if r == 0 && len(rows) > 1 {
+			rule(box[5], box[6], box[7])
+		}
+	}
+	rule(box[8], box[9], box[10])
+	return bw.Flush()
+}
+
+// displayWidth - returns the terminal columns s takes, wide east asian and
+// emoji runes take 2 and combining marks none.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || r == '\u200b':
+			// zero width
+		case wideRune(r):
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// wideRune - returns whether r is displayed two columns wide.
+func wideRune(r rune) bool {
+	return r >= 0x1100 && r <= 0x115f || r >= 0x2e80 && r <= 0xa4cf && r != 0x303f ||
+		r >= 0xac00 && r <= 0xd7a3 || r >= 0xf900 && r <= 0xfaff || r >= 0xfe30 && r <= 0xfe4f ||
+		r >= 0xff00 && r <= 0xff60 || r >= 0xffe0 && r <= 0xffe6 ||
+		r >= 0x1f300 && r <= 0x1f64f || r >= 0x1f900 && r <= 0x1f9ff || r >= 0x20000 && r <= 0x3fffd
+}
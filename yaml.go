@@ -0,0 +1,155 @@
+// Copyright 2017 phcurtis ghrepo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// yamlNode - a json value kept in document order, so yaml output has the
+// same field names and order as json output.
+type yamlNode struct {
+	keys   []string   // object keys, nil for arrays and scalars
+	elems  []yamlNode // object values or array elements
+	array  bool
+	object bool
+	scalar string // yaml text of a scalar
+}
+
+// writeYAML - writes v, anything json.Marshal accepts, as a yaml document.
+func writeYAML(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	node, err := yamlDecode(dec)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "---")
+	switch {
+	case node.object && len(node.keys) > 0:
+		yamlObject(bw, node, 0)
+	case node.array && len(node.elems) > 0:
+		yamlArray(bw, node, 0)
+	default:
+		fmt.Fprintln(bw, yamlInline(node))
+	}
+	return bw.Flush()
+}
+
+// yamlDecode - returns the next json value of dec as a yamlNode.
+func yamlDecode(dec *json.Decoder) (yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return yamlNode{}, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		node := yamlNode{object: t == '{', array: t == '['}
+		for dec.More() {
+			if node.object {
+				ktok, err := dec.Token()
+				if err != nil {
+					return node, err
+				}
+				node.keys = append(node.keys, ktok.(string))
+			}
+			elem, err := yamlDecode(dec)
+			if err != nil {
+				return node, err
+			}
+			node.elems = append(node.elems, elem)
+		}
+		_, err = dec.Token() // closing delim
+		return node, err
+	case string:
+		return yamlNode{scalar: yamlString(t)}, nil
+	case json.Number:
+		return yamlNode{scalar: t.String()}, nil
+	case bool:
+		return yamlNode{scalar: strconv.FormatBool(t)}, nil
+	default:
+		return yamlNode{scalar: "null"}, nil
+	}
+}
+
+// yamlString - returns s quoted when left plain yaml would read it as
+// something else, e.g. a number, bool or null, or fail to parse it.
+func yamlString(s string) string {
+	if s == "" || strings.ContainsAny(s, ":#{}[],&*!|>'\"%@`\n\t\\") || s != strings.TrimSpace(s) ||
+		strings.HasPrefix(s, "-") || strings.HasPrefix(s, "?") {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~", "y", "n":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// yamlInline - returns the yaml of a scalar or empty object/array node.
+func yamlInline(node yamlNode) string {
+	switch {
+	case node.object:
+		return "{}"
+	case node.array:
+		return "[]"
+	}
+	return node.scalar
+}
+
+// yamlNested - returns whether node needs lines of its own.
+func yamlNested(node yamlNode) bool {
+	return (node.object || node.array) && len(node.elems) > 0
+}
+
+func yamlObject(w io.Writer, node yamlNode, indent int) {
+	pad := strings.Repeat("  ", indent)
+	for i, k := range node.keys {
+		yamlEntry(w, pad+yamlString(k)+":", node.elems[i], indent)
+	}
+}
+
+func yamlArray(w io.Writer, node yamlNode, indent int) {
+	pad := strings.Repeat("  ", indent)
+	for _, elem := range node.elems {
+		if elem.object && len(elem.keys) > 0 {
+			// the first key goes on the "- " line, the rest align with it.
+			yamlEntry(w, pad+"- "+yamlString(elem.keys[0])+":", elem.elems[0], indent+1)
+			rest := yamlNode{object: true, keys: elem.keys[1:], elems: elem.elems[1:]}
+			yamlObject(w, rest, indent+1)
+			continue
+		}
+		yamlEntry(w, pad+"-", elem, indent)
+	}
+}
+
+// yamlEntry - writes prefix followed by value, nested values on the
+// following lines one level deeper than indent.
+func yamlEntry(w io.Writer, prefix string, value yamlNode, indent int) {
+	if !yamlNested(value) {
+		fmt.Fprintf(w, "%s %s\n", prefix, yamlInline(value))
+		return
+	}
+	fmt.Fprintln(w, prefix)
+	if value.object {
+		yamlObject(w, value, indent+1)
+	} else {
+		yamlArray(w, value, indent+1)
+	}
+}